@@ -0,0 +1,55 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/jsonpb"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// decodeAlternatives extracts the alternative query results from the webhook request
+func decodeAlternatives(beta betaRequest) ([]*df.QueryResult, error) {
+	unmarshaler := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	results := make([]*df.QueryResult, 0, len(beta.AlternativeQueryResults))
+	for _, raw := range beta.AlternativeQueryResults {
+		result := &df.QueryResult{}
+		if err := unmarshaler.Unmarshal(bytes.NewReader(raw), result); err != nil {
+			return nil, fmt.Errorf("unable to decode alternative query result: %v", err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// AlternativeResults returns the alternative query results dialogflow sends
+// with v2beta1 requests, e.g. intents or knowledge answers which matched with
+// a lower confidence. Dialogflow v2 requests never contain alternatives.
+func (w *Agent) AlternativeResults() []*df.QueryResult {
+	return w.alternatives
+}
+
+// DidYouMean returns the display names of the alternatively matched intents
+// with a detection confidence of at least minConfidence, best match first, so
+// handlers can ask "Did you mean X?" instead of proceeding with a weak match
+func (w *Agent) DidYouMean(minConfidence float32) []string {
+	alternatives := make([]*df.QueryResult, 0, len(w.alternatives))
+	matched := w.req.GetQueryResult().GetIntent().GetName()
+	for _, result := range w.alternatives {
+		intent := result.GetIntent()
+		if intent == nil || intent.Name == matched || result.IntentDetectionConfidence < minConfidence {
+			continue
+		}
+		alternatives = append(alternatives, result)
+	}
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		return alternatives[i].IntentDetectionConfidence > alternatives[j].IntentDetectionConfidence
+	})
+
+	names := make([]string, 0, len(alternatives))
+	for _, result := range alternatives {
+		names = append(names, result.Intent.DisplayName)
+	}
+	return names
+}
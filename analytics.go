@@ -0,0 +1,79 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"time"
+)
+
+// Event is an analytics event attributed to a conversation
+type Event struct {
+	Name     string                 `json:"name"`
+	Session  string                 `json:"session"`
+	UserID   string                 `json:"userId,omitempty"`
+	Intent   string                 `json:"intent"`
+	Platform string                 `json:"platform"`
+	Time     time.Time              `json:"time"`
+	Props    map[string]interface{} `json:"props,omitempty"`
+}
+
+// AnalyticsExporter receives the analytics events of each turn after the handler returned
+type AnalyticsExporter interface {
+	Export(events []Event) error
+}
+
+var analyticsExporter AnalyticsExporter
+
+// SetAnalyticsExporter sets the exporter receiving analytics events
+func SetAnalyticsExporter(e AnalyticsExporter) {
+	analyticsExporter = e
+}
+
+// TrackGoal marks a funnel milestone like "checkout_completed" for the current session
+func (w *Agent) TrackGoal(goal string, props map[string]interface{}) {
+	w.events = append(w.events, Event{
+		Name:     goal,
+		Session:  w.Session(),
+		UserID:   w.UserID(),
+		Intent:   w.req.GetQueryResult().GetIntent().GetDisplayName(),
+		Platform: w.req.GetOriginalDetectIntentRequest().GetSource(),
+		Time:     time.Now(),
+		Props:    props,
+	})
+}
+
+// exportEvents passes the events tracked in this turn to the exporter
+func (w *Agent) exportEvents() {
+	if analyticsExporter == nil || len(w.events) == 0 {
+		return
+	}
+	if w.capture("analytics", w.events) {
+		return
+	}
+	if err := analyticsExporter.Export(w.events); err != nil {
+		log.Printf("unable to export analytics events: %v", err)
+	}
+}
+
+// jsonExporter writes events as JSON lines
+type jsonExporter struct {
+	out io.Writer
+}
+
+// NewJSONExporter creates an exporter writing one JSON line per event, e.g.
+// to stdout for a CloudWatch logs subscription
+func NewJSONExporter(out io.Writer) AnalyticsExporter {
+	return &jsonExporter{out: out}
+}
+
+// Export writes the events
+func (e *jsonExporter) Export(events []Event) error {
+	enc := json.NewEncoder(e.out)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Attachment is a file sent by the user on a messenger platform
+type Attachment struct {
+	// Type is image, audio, video or file
+	Type     string
+	URL      string
+	Name     string
+	MimeType string
+	// FileID identifies telegram files, which are downloaded through the bot api
+	FileID string
+}
+
+// Uploader stores downloaded attachments and returns their location, e.g. an
+// S3 bucket storing the file under the key
+type Uploader interface {
+	Upload(key, contentType string, body io.Reader) (string, error)
+}
+
+var (
+	attachmentClient   = &http.Client{Timeout: 10 * time.Second}
+	attachmentUploader Uploader
+	telegramBotToken   string
+)
+
+// SetAttachmentHTTPClient sets the client downloading attachments, e.g. one
+// instrumented for tracing
+func SetAttachmentHTTPClient(c *http.Client) {
+	attachmentClient = c
+}
+
+// SetAttachmentUploader sets where RelayAttachment stores attachments
+func SetAttachmentUploader(u Uploader) {
+	attachmentUploader = u
+}
+
+// SetTelegramBotToken sets the token of the telegram bot, which is needed to
+// download files sent on telegram
+func SetTelegramBotToken(token string) {
+	telegramBotToken = token
+}
+
+// Attachments returns the files attached to the message of the user on
+// facebook messenger and telegram
+func (w *Agent) Attachments() []Attachment {
+	var attachments []Attachment
+	switch w.Source() {
+	case SourceFacebookMessenger:
+		for _, v := range w.payloadValue("data", "message", "attachments").GetListValue().GetValues() {
+			fields := v.GetStructValue().GetFields()
+			u := fields["payload"].GetStructValue().GetFields()["url"].GetStringValue()
+			if u == "" {
+				continue
+			}
+			attachments = append(attachments, Attachment{Type: fields["type"].GetStringValue(), URL: u})
+		}
+	case SourceTelegram:
+		// telegram sends several sizes of a photo, the last one is the largest
+		if photos := w.payloadValue("data", "message", "photo").GetListValue().GetValues(); len(photos) > 0 {
+			fields := photos[len(photos)-1].GetStructValue().GetFields()
+			attachments = append(attachments, Attachment{Type: "image", FileID: fields["file_id"].GetStringValue()})
+		}
+		for _, kind := range [][2]string{{"document", "file"}, {"audio", "audio"}, {"voice", "audio"}, {"video", "video"}} {
+			fields := w.payloadValue("data", "message", kind[0]).GetStructValue().GetFields()
+			if id := fields["file_id"].GetStringValue(); id != "" {
+				attachments = append(attachments, Attachment{
+					Type:     kind[1],
+					FileID:   id,
+					Name:     fields["file_name"].GetStringValue(),
+					MimeType: fields["mime_type"].GetStringValue(),
+				})
+			}
+		}
+	}
+	return attachments
+}
+
+// Download opens the content of the attachment, the caller closes it
+func (w *Agent) Download(a Attachment) (io.ReadCloser, string, error) {
+	u := a.URL
+	if u == "" && a.FileID != "" {
+		var err error
+		if u, err = w.telegramFileURL(a.FileID); err != nil {
+			return nil, "", err
+		}
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	w.CountCall()
+	res, err := attachmentClient.Do(req.WithContext(w.Context()))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to download attachment: %v", err)
+	}
+	if res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, "", fmt.Errorf("unable to download attachment: %v", res.Status)
+	}
+	contentType := a.MimeType
+	if contentType == "" {
+		contentType = res.Header.Get("Content-Type")
+	}
+	return res.Body, contentType, nil
+}
+
+// RelayAttachment downloads the attachment and stores it with the uploader.
+// It returns the location of the stored file.
+func (w *Agent) RelayAttachment(a Attachment) (string, error) {
+	if attachmentUploader == nil {
+		return "", fmt.Errorf("no attachment uploader configured")
+	}
+	name := a.Name
+	if name == "" {
+		name = a.FileID
+		if parsed, err := url.Parse(a.URL); err == nil && a.URL != "" {
+			name = path.Base(parsed.Path)
+		}
+	}
+	session := w.Session()[strings.LastIndex(w.Session(), "/")+1:]
+	key := path.Join(session, w.req.GetResponseId(), name)
+	if w.capture("upload", map[string]interface{}{"key": key, "attachment": a}) {
+		return "", nil
+	}
+
+	body, contentType, err := w.Download(a)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	location, err := attachmentUploader.Upload(key, contentType, body)
+	if err != nil {
+		return "", fmt.Errorf("unable to upload attachment: %v", err)
+	}
+	return location, nil
+}
+
+// telegramFileURL resolves the download url of a telegram file
+func (w *Agent) telegramFileURL(fileID string) (string, error) {
+	if telegramBotToken == "" {
+		return "", fmt.Errorf("no telegram bot token configured")
+	}
+	req, err := http.NewRequest("GET", "https://api.telegram.org/bot"+telegramBotToken+"/getFile?file_id="+url.QueryEscape(fileID), nil)
+	if err != nil {
+		return "", err
+	}
+	w.CountCall()
+	res, err := attachmentClient.Do(req.WithContext(w.Context()))
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve telegram file: %v", err)
+	}
+	defer res.Body.Close()
+	var file struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			FilePath string `json:"file_path"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&file); err != nil || !file.OK {
+		return "", fmt.Errorf("unable to resolve telegram file: %v", res.Status)
+	}
+	return "https://api.telegram.org/file/bot" + telegramBotToken + "/" + file.Result.FilePath, nil
+}
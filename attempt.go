@@ -0,0 +1,22 @@
+package lambdadialogflow
+
+// attemptContext keeps track of how often an action fired in a row
+const attemptContext = "lambdadialogflow-attempt"
+
+// Attempt returns how often the current action fired in a row, starting with 1.
+// Handlers use it to change the wording of a repeated prompt and to give up
+// after a few tries. The counter is reset as soon as a turn does not call Attempt.
+func (w *Agent) Attempt() int {
+	count := 1
+	if ctx := w.inputContext(attemptContext); ctx != nil {
+		fields := ctx.GetParameters().GetFields()
+		if fields["action"].GetStringValue() == w.Action() {
+			count = int(fields["count"].GetNumberValue()) + 1
+		}
+	}
+	w.OutputContext(attemptContext).
+		SetLifespan(1).
+		SetParam("action", w.Action()).
+		SetParam("count", count)
+	return count
+}
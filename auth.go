@@ -0,0 +1,155 @@
+package lambdadialogflow
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dialogflowScope is the OAuth2 scope required by the dialogflow API
+const dialogflowScope = "https://www.googleapis.com/auth/dialogflow"
+
+// serviceAccount is the part of a service account key file needed for authentication
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+	ProjectID   string `json:"project_id"`
+}
+
+// serviceAccountTokens exchanges signed JWTs of a service account for access tokens
+type serviceAccountTokens struct {
+	account serviceAccount
+	key     *rsa.PrivateKey
+	scope   string
+	client  *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// ServiceAccountTokens creates a TokenSource from the JSON key file of a
+// service account, tokens are cached until shortly before they expire. The
+// tokens are valid for the dialogflow API unless other scopes are given.
+func ServiceAccountTokens(keyFile []byte, scopes ...string) (TokenSource, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(keyFile, &account); err != nil {
+		return nil, fmt.Errorf("unable to decode service account key: %v", err)
+	}
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("service account key contains no private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse service account private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private key is not an RSA key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	if len(scopes) == 0 {
+		scopes = []string{dialogflowScope}
+	}
+	return &serviceAccountTokens{
+		account: account,
+		key:     key,
+		scope:   strings.Join(scopes, " "),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Token returns a cached access token or requests a new one
+func (s *serviceAccountTokens) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expires) {
+		return s.token, nil
+	}
+
+	assertion, err := s.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	res, err := s.client.PostForm(s.account.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %v: %s", res.Status, b)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(b, &token); err != nil {
+		return "", fmt.Errorf("unable to decode access token: %v", err)
+	}
+	s.token = token.AccessToken
+	s.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+// assertion creates the signed JWT which is exchanged for an access token
+func (s *serviceAccountTokens) assertion(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   s.account.ClientEmail,
+		"scope": s.scope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("unable to sign token request: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// NewClientFromEnv creates a client authenticated with the service account key
+// file named by the GOOGLE_APPLICATION_CREDENTIALS environment variable
+func NewClientFromEnv() (*Client, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		return nil, errors.New("GOOGLE_APPLICATION_CREDENTIALS is not set")
+	}
+	keyFile, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account key: %v", err)
+	}
+	tokens, err := ServiceAccountTokens(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(tokens), nil
+}
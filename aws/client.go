@@ -0,0 +1,47 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+// defaultHTTPClient is used by services without an HTTPClient
+var defaultHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// envRegion returns the region of the lambda
+func envRegion() string {
+	return os.Getenv("AWS_REGION")
+}
+
+// do sends a signed request with the JSON body to the service, reporting the
+// status code of the response, which is returned as an error if not 2xx
+func do(httpClient *http.Client, creds Credentials, method, url, service, region string, header http.Header, body []byte) (int, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	creds.sign(req, body, service, region, time.Now())
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("%v returned %v: %s", service, res.Status, b)
+	}
+	return res.StatusCode, nil
+}
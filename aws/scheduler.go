@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	ld "github.com/holgerarendt/lambda-dialogflow"
+)
+
+// Scheduler is a lambdadialogflow.Scheduler creating one-off EventBridge
+// Scheduler schedules, set with lambdadialogflow.SetScheduler. The schedules
+// invoke the lambda running lambdadialogflow.StartScheduled and delete
+// themselves afterwards.
+type Scheduler struct {
+	HTTPClient  *http.Client
+	Credentials Credentials
+	// Endpoint defaults to the Scheduler API of the Region
+	Endpoint string
+	Region   string
+	// Target is the ARN of the lambda running StartScheduled
+	Target string
+	// Role is the ARN of the role EventBridge Scheduler invokes the target with
+	Role string
+	// Group is the schedule group, the default group is used if empty
+	Group string
+}
+
+// NewScheduler creates a scheduler using the credentials and region of the lambda
+func NewScheduler(target, role string) *Scheduler {
+	return &Scheduler{
+		HTTPClient:  defaultHTTPClient,
+		Credentials: EnvCredentials(),
+		Region:      envRegion(),
+		Target:      target,
+		Role:        role,
+	}
+}
+
+// schedule is the body of the CreateSchedule request
+type schedule struct {
+	ScheduleExpression         string             `json:"ScheduleExpression"`
+	ScheduleExpressionTimezone string             `json:"ScheduleExpressionTimezone"`
+	FlexibleTimeWindow         flexibleTimeWindow `json:"FlexibleTimeWindow"`
+	Target                     target             `json:"Target"`
+	ActionAfterCompletion      string             `json:"ActionAfterCompletion"`
+	GroupName                  string             `json:"GroupName,omitempty"`
+}
+
+type flexibleTimeWindow struct {
+	Mode string `json:"Mode"`
+}
+
+type target struct {
+	Arn     string `json:"Arn"`
+	RoleArn string `json:"RoleArn"`
+	Input   string `json:"Input"`
+}
+
+// Schedule creates a schedule invoking the target with the action at the
+// given time. Scheduling the same action of a session for the same time
+// twice creates one schedule only.
+func (s *Scheduler) Schedule(at time.Time, action ld.ScheduledAction) error {
+	input, err := json.Marshal(action)
+	if err != nil {
+		return fmt.Errorf("unable to encode scheduled action: %v", err)
+	}
+	body, err := json.Marshal(schedule{
+		ScheduleExpression:         "at(" + at.UTC().Format("2006-01-02T15:04:05") + ")",
+		ScheduleExpressionTimezone: "UTC",
+		FlexibleTimeWindow:         flexibleTimeWindow{Mode: "OFF"},
+		Target:                     target{Arn: s.Target, RoleArn: s.Role, Input: string(input)},
+		ActionAfterCompletion:      "DELETE",
+		GroupName:                  s.Group,
+	})
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	status, err := do(s.HTTPClient, s.Credentials, "POST", s.scheduleURL(at, action), "scheduler", s.Region, header, body)
+	if status == http.StatusConflict {
+		// the schedule was created by a retry of the same request
+		return nil
+	}
+	return err
+}
+
+// scheduleURL returns the url of the schedule, named after the session,
+// action and time
+func (s *Scheduler) scheduleURL(at time.Time, action ld.ScheduledAction) string {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://scheduler.%v.amazonaws.com", s.Region)
+	}
+	sum := sha256.Sum256([]byte(action.Session + "|" + action.Action + "|" + at.UTC().Format(time.RFC3339)))
+	name := "lambdadialogflow-" + hex.EncodeToString(sum[:16])
+	return endpoint + "/schedules/" + url.PathEscape(name)
+}
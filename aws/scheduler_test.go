@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ld "github.com/holgerarendt/lambda-dialogflow"
+)
+
+func TestScheduler(t *testing.T) {
+	var paths []string
+	var got schedule
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(req.Header.Get("Authorization"), "/eu-west-1/scheduler/aws4_request") {
+			t.Errorf("Authorization = %v", req.Header.Get("Authorization"))
+		}
+		for _, path := range paths {
+			if path == req.URL.Path {
+				rw.WriteHeader(http.StatusConflict)
+				return
+			}
+		}
+		paths = append(paths, req.URL.Path)
+		b, _ := ioutil.ReadAll(req.Body)
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Error(err)
+		}
+		rw.Write([]byte(`{"ScheduleArn":"arn"}`))
+	}))
+	defer srv.Close()
+
+	s := &Scheduler{
+		Credentials: Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		Endpoint:    srv.URL,
+		Region:      "eu-west-1",
+		Target:      "arn:aws:lambda:eu-west-1:123:function:scheduled",
+		Role:        "arn:aws:iam::123:role/scheduler",
+	}
+	at := time.Date(2020, 5, 1, 14, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	action := ld.ScheduledAction{Action: "reminder", Session: "projects/p/agent/sessions/s", Params: map[string]interface{}{"text": "call mum"}}
+	if err := s.Schedule(at, action); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Schedule(at, action); err != nil {
+		t.Errorf("scheduling twice: %v", err)
+	}
+	if len(paths) != 1 || !strings.HasPrefix(paths[0], "/schedules/lambdadialogflow-") {
+		t.Errorf("paths = %v", paths)
+	}
+
+	if got.ScheduleExpression != "at(2020-05-01T12:30:00)" || got.ScheduleExpressionTimezone != "UTC" || got.ActionAfterCompletion != "DELETE" {
+		t.Errorf("schedule = %+v", got)
+	}
+	if got.Target.Arn != s.Target || got.Target.RoleArn != s.Role {
+		t.Errorf("target = %+v", got.Target)
+	}
+	var input ld.ScheduledAction
+	if err := json.Unmarshal([]byte(got.Target.Input), &input); err != nil || input.Action != "reminder" || input.Params["text"] != "call mum" {
+		t.Errorf("input = %v (%v)", got.Target.Input, err)
+	}
+}
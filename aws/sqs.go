@@ -0,0 +1,59 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	ld "github.com/holgerarendt/lambda-dialogflow"
+)
+
+// Queue is a lambdadialogflow.DeadLetterQueue sending the JSON encoded dead
+// letters to an SQS queue, set with lambdadialogflow.SetDeadLetterQueue
+type Queue struct {
+	HTTPClient  *http.Client
+	Credentials Credentials
+	// Endpoint defaults to the SQS API of the Region
+	Endpoint string
+	Region   string
+	// URL is the url of the queue
+	URL string
+}
+
+// NewQueue creates a queue using the credentials and region of the lambda
+func NewQueue(queueURL string) *Queue {
+	return &Queue{
+		HTTPClient:  defaultHTTPClient,
+		Credentials: EnvCredentials(),
+		Region:      envRegion(),
+		URL:         queueURL,
+	}
+}
+
+// sendMessage is the body of the SendMessage request
+type sendMessage struct {
+	QueueURL    string `json:"QueueUrl"`
+	MessageBody string `json:"MessageBody"`
+}
+
+// Send sends the dead letter as message
+func (q *Queue) Send(letter ld.DeadLetter) error {
+	message, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("unable to encode dead letter: %v", err)
+	}
+	body, err := json.Marshal(sendMessage{QueueURL: q.URL, MessageBody: string(message)})
+	if err != nil {
+		return err
+	}
+	endpoint := q.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sqs.%v.amazonaws.com", q.Region)
+	}
+	header := http.Header{
+		"Content-Type": {"application/x-amz-json-1.0"},
+		"X-Amz-Target": {"AmazonSQS.SendMessage"},
+	}
+	_, err = do(q.HTTPClient, q.Credentials, "POST", endpoint+"/", "sqs", q.Region, header, body)
+	return err
+}
@@ -0,0 +1,61 @@
+package aws
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ld "github.com/holgerarendt/lambda-dialogflow"
+)
+
+func TestQueue(t *testing.T) {
+	var got sendMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Amz-Target") != "AmazonSQS.SendMessage" {
+			t.Errorf("X-Amz-Target = %v", req.Header.Get("X-Amz-Target"))
+		}
+		if !strings.Contains(req.Header.Get("Authorization"), "/eu-west-1/sqs/aws4_request") ||
+			!strings.Contains(req.Header.Get("Authorization"), "x-amz-security-token;x-amz-target") {
+			t.Errorf("Authorization = %v", req.Header.Get("Authorization"))
+		}
+		b, _ := ioutil.ReadAll(req.Body)
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Error(err)
+		}
+		rw.Write([]byte(`{"MessageId":"1"}`))
+	}))
+	defer srv.Close()
+
+	q := &Queue{
+		Credentials: Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"},
+		Endpoint:    srv.URL,
+		Region:      "eu-west-1",
+		URL:         "https://sqs.eu-west-1.amazonaws.com/123/dead-letters",
+	}
+	if err := q.Send(ld.DeadLetter{Action: "order", Class: ld.ErrorPanic, Error: "boom"}); err != nil {
+		t.Fatal(err)
+	}
+	var letter ld.DeadLetter
+	if err := json.Unmarshal([]byte(got.MessageBody), &letter); err != nil || letter.Action != "order" || letter.Class != ld.ErrorPanic {
+		t.Errorf("message = %v (%v)", got.MessageBody, err)
+	}
+	if got.QueueURL != q.URL {
+		t.Errorf("queue url = %v", got.QueueURL)
+	}
+}
+
+func TestQueueError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte(`{"__type":"com.amazonaws.sqs#QueueDoesNotExist"}`))
+	}))
+	defer srv.Close()
+
+	q := &Queue{Endpoint: srv.URL, Region: "eu-west-1", URL: "https://sqs.eu-west-1.amazonaws.com/123/missing"}
+	if err := q.Send(ld.DeadLetter{}); err == nil || !strings.Contains(err.Error(), "QueueDoesNotExist") {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package lambdadialogflow
+
+import "encoding/json"
+
+// betaRequest holds the fields of v2beta1 webhook requests which are missing in the v2 protos
+type betaRequest struct {
+	QueryResult             betaQueryResult   `json:"queryResult"`
+	AlternativeQueryResults []json.RawMessage `json:"alternativeQueryResults"`
+}
+
+// betaQueryResult holds the fields of v2beta1 query results which are missing in the v2 protos
+type betaQueryResult struct {
+	KnowledgeAnswers struct {
+		Answers []KnowledgeAnswer `json:"answers"`
+	} `json:"knowledgeAnswers"`
+}
+
+// decodeBeta decodes the fields of v2beta1 requests missing in the v2 protos
+func decodeBeta(fields rawRequest) (betaRequest, error) {
+	var beta betaRequest
+	if raw, ok := fields["queryResult"]; ok {
+		if err := json.Unmarshal(raw, &beta.QueryResult); err != nil {
+			return beta, err
+		}
+	}
+	if raw, ok := fields["alternativeQueryResults"]; ok {
+		if err := json.Unmarshal(raw, &beta.AlternativeQueryResults); err != nil {
+			return beta, err
+		}
+	}
+	return beta, nil
+}
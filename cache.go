@@ -0,0 +1,160 @@
+package lambdadialogflow
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang/protobuf/proto"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// cachedResponse is a response kept in the cache of the lambda instance
+type cachedResponse struct {
+	key     string
+	res     *df.WebhookResponse
+	expires time.Time
+	// signedIn responses were answered by a RequireAuth handler and are only
+	// served to signed in users
+	signedIn bool
+}
+
+var (
+	cacheTTL   = make(map[string]time.Duration)
+	cacheSize  = 1000
+	cacheMutex sync.Mutex
+	cache      = make(map[string]*list.Element)
+	// cacheOrder holds the cached responses, most recently used first
+	cacheOrder = list.New()
+)
+
+// CacheResponses caches the responses of the handler for action for ttl.
+// Responses are keyed by action, parameters and language and kept in the
+// memory of the lambda instance, so only use it for informational intents
+// whose answer does not depend on the user. Requests with a
+// "Cache-Control: no-cache" header bypass the cache. Only turns answered by
+// the handler itself are cached, not those taken over by a pending flow like
+// the consent gate or a confirmation. The middlewares run for cached
+// responses too, and responses of handlers wrapped in RequireAuth are only
+// served to signed in users.
+func CacheResponses(action string, ttl time.Duration) {
+	cacheTTL[action] = ttl
+}
+
+// SetCacheSize limits the number of cached responses, the least recently used
+// response is evicted first. It defaults to 1000.
+func SetCacheSize(size int) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	cacheSize = size
+	evictResponses()
+}
+
+// evictResponses removes the least recently used responses beyond the cache
+// size, the mutex must be held
+func evictResponses() {
+	for cacheOrder.Len() > cacheSize && cacheOrder.Len() > 0 {
+		oldest := cacheOrder.Back()
+		cacheOrder.Remove(oldest)
+		delete(cache, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// NoCache keeps the response of the current request out of the cache
+func (w *Agent) NoCache() {
+	w.noCache = true
+}
+
+// cacheKey returns the key of the request within the response cache, or an
+// empty key if the request must not be cached
+func (w *Agent) cacheKey(req events.APIGatewayProxyRequest) string {
+	if _, ok := cacheTTL[w.Action()]; !ok || w.dryRun {
+		return ""
+	}
+	if strings.Contains(strings.ToLower(header(req.Headers, "Cache-Control")), "no-cache") {
+		return ""
+	}
+	params, err := json.Marshal(fromStruct(w.req.GetQueryResult().GetParameters()))
+	if err != nil {
+		return ""
+	}
+	key := w.Action() + "|" + w.req.GetQueryResult().GetLanguageCode() + "|" + string(params)
+	return fmt.Sprintf("%p|%v", w.router, strings.ToLower(key))
+}
+
+// cached wraps the handler so it is skipped if a response is cached for key.
+// It is applied inside the middlewares, which run for cached responses too.
+func cached(key string, handler WebhookHandler) WebhookHandler {
+	return func(w *Agent) {
+		if res, ok := w.cachedResponse(key); ok {
+			w.res = res
+			w.cacheHit = true
+			return
+		}
+		handler(w)
+	}
+}
+
+// cachedResponse returns the cached response for the key, with the output
+// contexts moved to the session of the current request
+func (w *Agent) cachedResponse(key string) (*df.WebhookResponse, bool) {
+	if key == "" {
+		return nil, false
+	}
+	cacheMutex.Lock()
+	elem, ok := cache[key]
+	var entry *cachedResponse
+	if ok {
+		entry = elem.Value.(*cachedResponse)
+		if time.Now().After(entry.expires) {
+			cacheOrder.Remove(elem)
+			delete(cache, key)
+			ok = false
+		} else {
+			cacheOrder.MoveToFront(elem)
+		}
+	}
+	cacheMutex.Unlock()
+	if !ok || entry.signedIn && !w.SignedIn() {
+		return nil, false
+	}
+
+	res := proto.Clone(entry.res).(*df.WebhookResponse)
+	for _, ctx := range res.OutputContexts {
+		ctx.Name = w.contextName(ctx.Name)
+	}
+	return res, true
+}
+
+// cacheResponse stores the response of the current request. Responses with
+// session entities are never cached since they belong to the session.
+func (w *Agent) cacheResponse(key string) {
+	if key == "" || w.noCache || len(w.res.SessionEntityTypes) > 0 {
+		return
+	}
+	res := proto.Clone(w.res).(*df.WebhookResponse)
+	for _, ctx := range res.OutputContexts {
+		ctx.Name = shortContextName(ctx.Name)
+	}
+
+	entry := &cachedResponse{
+		key:      key,
+		res:      res,
+		expires:  time.Now().Add(cacheTTL[w.Action()]),
+		signedIn: w.authRequired,
+	}
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	if elem, ok := cache[key]; ok {
+		elem.Value = entry
+		cacheOrder.MoveToFront(elem)
+		return
+	}
+	cache[key] = cacheOrder.PushFront(entry)
+	evictResponses()
+}
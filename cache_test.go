@@ -0,0 +1,144 @@
+package lambdadialogflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func cityRequest(id, city string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{Body: `{"responseId":"` + id + `","session":"projects/p/agent/sessions/s",` +
+		`"queryResult":{"action":"weather","languageCode":"en","parameters":{"city":"` + city + `"}}}`}
+}
+
+func TestCacheResponses(t *testing.T) {
+	CacheResponses("weather", time.Minute)
+	SetCacheSize(2)
+	defer func() {
+		delete(cacheTTL, "weather")
+		SetCacheSize(0)
+		SetCacheSize(1000)
+	}()
+
+	calls := map[string]int{}
+	r := NewRouter()
+	r.Register("weather", func(w *Agent) {
+		city := w.GetStringParam("city")
+		calls[city]++
+		w.Say("sunny in " + city)
+	})
+	serve := func(id, city string) {
+		t.Helper()
+		resp, err := r.ServeContext(context.Background(), cityRequest(id, city))
+		if err != nil || !strings.Contains(resp.Body, "sunny in "+city) {
+			t.Fatalf("response for %v = %v, %v", city, resp.Body, err)
+		}
+	}
+
+	serve("1", "berlin")
+	serve("2", "berlin")
+	if calls["berlin"] != 1 {
+		t.Errorf("handler called %d times for berlin, want cached response", calls["berlin"])
+	}
+
+	// paris and rome exceed the cache size, berlin was least recently used
+	serve("3", "paris")
+	serve("4", "paris")
+	serve("5", "rome")
+	serve("6", "berlin")
+	if calls["berlin"] != 2 || calls["paris"] != 1 {
+		t.Errorf("calls = %v, want berlin evicted and paris cached", calls)
+	}
+	if n := len(cache); n != 2 {
+		t.Errorf("cache holds %d responses, want 2", n)
+	}
+}
+
+func TestCacheSkipsConsentGate(t *testing.T) {
+	CacheResponses("weather", time.Minute)
+	RequireConsent(&ConsentFlow{Disclosure: "This call is recorded.", Granted: "Thanks."})
+	defer func() {
+		delete(cacheTTL, "weather")
+		RequireConsent(nil)
+		SetCacheSize(0)
+		SetCacheSize(1000)
+	}()
+
+	r := NewRouter()
+	r.Register("weather", func(w *Agent) { w.Say("sunny") })
+	call := func(id, session, contexts string) string {
+		t.Helper()
+		body := `{"responseId":"` + id + `","session":"projects/p/agent/sessions/` + session + `",` +
+			`"queryResult":{"action":"weather","languageCode":"en","outputContexts":[` + contexts + `]},` +
+			`"originalDetectIntentRequest":{"source":"GOOGLE_TELEPHONY"}}`
+		resp, err := r.Serve(events.APIGatewayProxyRequest{Body: body})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Body
+	}
+	consented := `{"name":"projects/p/agent/sessions/b/contexts/lambdadialogflow-consent","lifespanCount":5,"parameters":{"granted":true}}`
+
+	if body := call("1", "a", ""); !strings.Contains(body, "recorded") {
+		t.Fatalf("first caller got %v, want the disclosure", body)
+	}
+	if body := call("2", "b", consented); !strings.Contains(body, "sunny") {
+		t.Errorf("consented caller got %v, want the weather", body)
+	}
+	if body := call("3", "c", ""); !strings.Contains(body, "recorded") {
+		t.Errorf("new caller got %v, want the disclosure", body)
+	}
+}
+
+func TestCacheRequireAuth(t *testing.T) {
+	CacheResponses("balance", time.Minute)
+	defer func() {
+		delete(cacheTTL, "balance")
+		SetCacheSize(0)
+		SetCacheSize(1000)
+	}()
+
+	calls, middlewareCalls := 0, 0
+	r := NewRouter()
+	r.Use(func(next WebhookHandler) WebhookHandler {
+		return func(w *Agent) {
+			middlewareCalls++
+			next(w)
+		}
+	})
+	r.Register("balance", RequireAuth(func(w *Agent) {
+		calls++
+		w.Say("the balance is 42")
+	}))
+	call := func(id, payload string) string {
+		t.Helper()
+		body := `{"responseId":"` + id + `","session":"projects/p/agent/sessions/s",` +
+			`"queryResult":{"action":"balance","languageCode":"en"},` +
+			`"originalDetectIntentRequest":{"payload":` + payload + `}}`
+		resp, err := r.Serve(events.APIGatewayProxyRequest{Body: body})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Body
+	}
+	signedIn := `{"user":{"accessToken":"token"}}`
+
+	if body := call("1", `{}`); !strings.Contains(body, "link your account") {
+		t.Fatalf("signed out user got %v, want the sign-in prompt", body)
+	}
+	if body := call("2", signedIn); !strings.Contains(body, "42") {
+		t.Errorf("signed in user got %v, want the balance", body)
+	}
+	if body := call("3", `{}`); !strings.Contains(body, "link your account") {
+		t.Errorf("signed out user got %v, want the sign-in prompt", body)
+	}
+	if body := call("4", signedIn); !strings.Contains(body, "42") || calls != 1 {
+		t.Errorf("signed in user got %v with %d handler calls, want the cached balance", body, calls)
+	}
+	if middlewareCalls != 4 {
+		t.Errorf("middleware ran %d times, want 4", middlewareCalls)
+	}
+}
@@ -0,0 +1,65 @@
+package lambdadialogflow
+
+// canvasContext keeps the state last sent to the interactive canvas web app
+const canvasContext = "lambdadialogflow-canvas"
+
+// Canvas is an interactive canvas response for smart displays
+type Canvas struct {
+	// URL of the web app, only needed on the first canvas response
+	URL string
+	// State is passed to the onUpdate callback of the web app
+	State map[string]interface{}
+	// SuppressMic keeps the microphone closed after the response
+	SuppressMic bool
+}
+
+// SetCanvas answers with an interactive canvas response, the text is spoken
+// alongside. The state is kept, so CanvasState returns it on the next turns.
+// Surfaces without Capabilities().InteractiveCanvas only get the text.
+func (w *Agent) SetCanvas(text string, canvas Canvas) {
+	w.Say(text)
+	if canvas.State != nil {
+		// like the web app, the context merges the updated state into the previous one
+		ctx := w.OutputContext(canvasContext).SetLifespan(MaxLifespan)
+		for key, value := range w.CanvasState() {
+			ctx.SetParam(key, value)
+		}
+		for key, value := range canvas.State {
+			ctx.SetParam(key, value)
+		}
+	}
+	if !w.Capabilities().InteractiveCanvas {
+		return
+	}
+
+	html := map[string]interface{}{"updatedState": canvas.State, "suppressMic": canvas.SuppressMic}
+	if canvas.URL != "" {
+		html["url"] = canvas.URL
+	}
+	w.AddPayloadStruct("google", map[string]interface{}{
+		"expectUserResponse": true,
+		"richResponse": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"simpleResponse": map[string]interface{}{"textToSpeech": text}},
+				map[string]interface{}{"htmlResponse": html},
+			},
+		},
+	})
+}
+
+// CanvasState returns the state last sent to the web app. Input of the web
+// app, sent with interactiveCanvas.sendTextQuery, arrives as query text.
+func (w *Agent) CanvasState() map[string]interface{} {
+	state := make(map[string]interface{})
+	if ctx := w.inputContext(canvasContext); ctx != nil {
+		state = fromStruct(ctx.Parameters)
+	}
+	for _, ctx := range w.res.OutputContexts {
+		if shortContextName(ctx.Name) == canvasContext {
+			for key, value := range fromStruct(ctx.Parameters) {
+				state[key] = value
+			}
+		}
+	}
+	return state
+}
@@ -0,0 +1,40 @@
+package lambdadialogflow
+
+// Capabilities are the output capabilities of the surface the user talks on
+type Capabilities struct {
+	Screen            bool
+	Audio             bool
+	MediaAudio        bool
+	WebBrowser        bool
+	InteractiveCanvas bool
+}
+
+// googleCapabilities maps the surface capabilities of actions on google
+var googleCapabilities = map[string]func(*Capabilities){
+	"actions.capability.SCREEN_OUTPUT":        func(c *Capabilities) { c.Screen = true },
+	"actions.capability.AUDIO_OUTPUT":         func(c *Capabilities) { c.Audio = true },
+	"actions.capability.MEDIA_RESPONSE_AUDIO": func(c *Capabilities) { c.MediaAudio = true },
+	"actions.capability.WEB_BROWSER":          func(c *Capabilities) { c.WebBrowser = true },
+	"actions.capability.INTERACTIVE_CANVAS":   func(c *Capabilities) { c.InteractiveCanvas = true },
+}
+
+// Capabilities returns the output capabilities of the surface. Actions on
+// google reports them per request, phone calls are audio only and all other
+// integrations are assumed to be text chats with links.
+func (w *Agent) Capabilities() Capabilities {
+	var c Capabilities
+	switch w.Source() {
+	case SourceGoogleAssistant:
+		for _, v := range w.payloadValue("surface", "capabilities").GetListValue().GetValues() {
+			if set := googleCapabilities[v.GetStructValue().GetFields()["name"].GetStringValue()]; set != nil {
+				set(&c)
+			}
+		}
+	case SourcePhoneGateway:
+		c.Audio = true
+	default:
+		c.Screen = true
+		c.WebBrowser = true
+	}
+	return c
+}
@@ -0,0 +1,59 @@
+package lambdadialogflow
+
+import df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+
+// CardButton is a button of a card, the postback is sent as query when tapped
+// or opened when it is a url
+type CardButton struct {
+	Text     string
+	Postback string
+}
+
+// AddCard adds a card message, rendered by messenger integrations like
+// facebook, telegram and slack
+func (w *Agent) AddCard(title, subtitle, imageURL string, buttons ...CardButton) {
+	card := &df.Intent_Message_Card{Title: title, Subtitle: subtitle, ImageUri: imageURL}
+	for _, b := range buttons {
+		card.Buttons = append(card.Buttons, &df.Intent_Message_Card_Button{Text: b.Text, Postback: b.Postback})
+	}
+	w.AddMessage(&df.Intent_Message{Message: &df.Intent_Message_Card_{Card: card}})
+}
+
+// AddImage adds an image message
+func (w *Agent) AddImage(url string) {
+	w.AddMessage(&df.Intent_Message{Message: &df.Intent_Message_Image_{
+		Image: &df.Intent_Message_Image{ImageUri: url},
+	}})
+}
+
+// AddQuickReplies adds quick reply buttons below the title
+func (w *Agent) AddQuickReplies(title string, replies ...string) {
+	w.AddMessage(&df.Intent_Message{Message: &df.Intent_Message_QuickReplies_{
+		QuickReplies: &df.Intent_Message_QuickReplies{Title: title, QuickReplies: replies},
+	}})
+}
+
+// AddSuggestions adds suggestion chips for actions on google. The assistant
+// only shows them next to a simple response, e.g. one added by SayWithSSML.
+func (w *Agent) AddSuggestions(chips ...string) {
+	suggestions := make([]*df.Intent_Message_Suggestion, len(chips))
+	for i, chip := range chips {
+		suggestions[i] = &df.Intent_Message_Suggestion{Title: chip}
+	}
+	w.AddMessage(&df.Intent_Message{
+		Platform: df.Intent_Message_ACTIONS_ON_GOOGLE,
+		Message:  &df.Intent_Message_Suggestions_{Suggestions: &df.Intent_Message_Suggestions{Suggestions: suggestions}},
+	})
+}
+
+// AddLinkOutSuggestion adds a suggestion chip opening a web page for actions
+// on google
+func (w *Agent) AddLinkOutSuggestion(name, url string) {
+	w.AddMessage(&df.Intent_Message{
+		Platform: df.Intent_Message_ACTIONS_ON_GOOGLE,
+		Message: &df.Intent_Message_LinkOutSuggestion_{LinkOutSuggestion: &df.Intent_Message_LinkOutSuggestion{
+			DestinationName: name,
+			Uri:             url,
+		}},
+	})
+}
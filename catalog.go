@@ -0,0 +1,213 @@
+package lambdadialogflow
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Catalog is the definition of the dialogflow agent as found in its export,
+// e.g. to build prompts or suggestions matching the live agent
+type Catalog struct {
+	intents map[string]*CatalogIntent
+}
+
+// CatalogIntent is an intent of the agent export
+type CatalogIntent struct {
+	Name          string
+	Action        string
+	Fallback      bool
+	InputContexts []string
+	Events        []string
+	parameters    []CatalogParameter
+	phrases       map[string][]string
+}
+
+// CatalogParameter is a parameter of an intent
+type CatalogParameter struct {
+	Name     string
+	Entity   string
+	Required bool
+	IsList   bool
+	// Prompts by language code
+	Prompts map[string][]string
+}
+
+// exportIntent is the json format of intents in the agent export
+type exportIntent struct {
+	Name     string   `json:"name"`
+	Contexts []string `json:"contexts"`
+	Fallback bool     `json:"fallbackIntent"`
+	Events   []struct {
+		Name string `json:"name"`
+	} `json:"events"`
+	Responses []struct {
+		Action     string `json:"action"`
+		Parameters []struct {
+			Name     string `json:"name"`
+			DataType string `json:"dataType"`
+			Required bool   `json:"required"`
+			IsList   bool   `json:"isList"`
+			Prompts  []struct {
+				Lang  string `json:"lang"`
+				Value string `json:"value"`
+			} `json:"prompts"`
+		} `json:"parameters"`
+	} `json:"responses"`
+}
+
+// exportPhrase is the json format of training phrases in the agent export
+type exportPhrase struct {
+	Data []struct {
+		Text string `json:"text"`
+	} `json:"data"`
+}
+
+// LoadCatalog reads the intents of an unpacked agent export, e.g. embedded
+// with go:embed
+func LoadCatalog(fsys fs.FS) (*Catalog, error) {
+	files, err := fs.Glob(fsys, "intents/*.json")
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{intents: make(map[string]*CatalogIntent)}
+	usersays := make(map[string][]string)
+	for _, file := range files {
+		if strings.Contains(path.Base(file), "_usersays_") {
+			usersays[file] = nil
+			continue
+		}
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var export exportIntent
+		if err := json.Unmarshal(b, &export); err != nil {
+			return nil, fmt.Errorf("unable to decode intent %v: %v", file, err)
+		}
+		intent := &CatalogIntent{
+			Name:          export.Name,
+			Fallback:      export.Fallback,
+			InputContexts: export.Contexts,
+			phrases:       make(map[string][]string),
+		}
+		for _, event := range export.Events {
+			intent.Events = append(intent.Events, event.Name)
+		}
+		for _, res := range export.Responses {
+			if intent.Action == "" {
+				intent.Action = res.Action
+			}
+			for _, p := range res.Parameters {
+				param := CatalogParameter{Name: p.Name, Entity: p.DataType, Required: p.Required, IsList: p.IsList, Prompts: make(map[string][]string)}
+				for _, prompt := range p.Prompts {
+					param.Prompts[prompt.Lang] = append(param.Prompts[prompt.Lang], prompt.Value)
+				}
+				intent.parameters = append(intent.parameters, param)
+			}
+		}
+		c.intents[strings.TrimSuffix(path.Base(file), ".json")] = intent
+	}
+
+	// training phrases are kept in <intent>_usersays_<lang>.json next to the intent
+	for file := range usersays {
+		base := strings.TrimSuffix(path.Base(file), ".json")
+		i := strings.LastIndex(base, "_usersays_")
+		intent := c.intents[base[:i]]
+		if intent == nil {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var phrases []exportPhrase
+		if err := json.Unmarshal(b, &phrases); err != nil {
+			return nil, fmt.Errorf("unable to decode training phrases %v: %v", file, err)
+		}
+		lang := base[i+len("_usersays_"):]
+		for _, phrase := range phrases {
+			var text strings.Builder
+			for _, part := range phrase.Data {
+				text.WriteString(part.Text)
+			}
+			intent.phrases[lang] = append(intent.phrases[lang], text.String())
+		}
+	}
+
+	// index by intent name instead of file name
+	byName := make(map[string]*CatalogIntent, len(c.intents))
+	for _, intent := range c.intents {
+		byName[intent.Name] = intent
+	}
+	c.intents = byName
+	return c, nil
+}
+
+// LoadCatalogZip reads the intents of an agent export zip file, e.g.
+// downloaded from S3 at startup
+func LoadCatalogZip(data []byte) (*Catalog, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open agent export: %v", err)
+	}
+	return LoadCatalog(r)
+}
+
+// Intent returns the intent with the given display name or action, or nil if
+// unknown
+func (c *Catalog) Intent(name string) *CatalogIntent {
+	if intent := c.intents[name]; intent != nil {
+		return intent
+	}
+	for _, intent := range c.Intents() {
+		if intent.Action == name {
+			return intent
+		}
+	}
+	return nil
+}
+
+// Intents returns all intents sorted by name
+func (c *Catalog) Intents() []*CatalogIntent {
+	intents := make([]*CatalogIntent, 0, len(c.intents))
+	for _, intent := range c.intents {
+		intents = append(intents, intent)
+	}
+	sort.Slice(intents, func(i, j int) bool {
+		return intents[i].Name < intents[j].Name
+	})
+	return intents
+}
+
+// Candidates returns the intents with an action as candidates for
+// SuggestIntents, labeled with their display name
+func (c *Catalog) Candidates(languageCode string) []IntentCandidate {
+	var candidates []IntentCandidate
+	for _, intent := range c.Intents() {
+		if intent.Action == "" || intent.Fallback {
+			continue
+		}
+		candidates = append(candidates, IntentCandidate{
+			Label:   intent.Name,
+			Action:  intent.Action,
+			Phrases: intent.TrainingPhrases(languageCode),
+		})
+	}
+	return candidates
+}
+
+// Parameters returns the parameters of the intent
+func (i *CatalogIntent) Parameters() []CatalogParameter {
+	return i.parameters
+}
+
+// TrainingPhrases returns the training phrases of the intent in a language
+func (i *CatalogIntent) TrainingPhrases(languageCode string) []string {
+	return i.phrases[languageCode]
+}
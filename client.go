@@ -0,0 +1,165 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// DefaultEndpoint is the base url of the dialogflow v2 REST API
+const DefaultEndpoint = "https://dialogflow.googleapis.com/v2"
+
+// TokenSource provides OAuth2 access tokens for the dialogflow API
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Client calls the dialogflow API from outside of a webhook request, e.g. to
+// push the result of a long running operation back into the conversation
+type Client struct {
+	HTTPClient *http.Client
+	Tokens     TokenSource
+	Endpoint   string
+}
+
+// NewClient creates a client authenticating with the given tokens
+func NewClient(tokens TokenSource) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		Tokens:     tokens,
+		Endpoint:   DefaultEndpoint,
+	}
+}
+
+// DetectEvent triggers an event in a session, so dialogflow matches the
+// intent listening on that event
+func (c *Client) DetectEvent(session, event, languageCode string, params map[string]interface{}) (*df.DetectIntentResponse, error) {
+	req := &df.DetectIntentRequest{
+		QueryInput: &df.QueryInput{
+			Input: &df.QueryInput_Event{
+				Event: &df.EventInput{
+					Name:         event,
+					Parameters:   toValue(params).GetStructValue(),
+					LanguageCode: languageCode,
+				},
+			},
+		},
+	}
+	res := &df.DetectIntentResponse{}
+	if err := c.call("POST", session+":detectIntent", req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DetectText sends a text query in a session, e.g. a message a relay received
+// from a platform without dialogflow integration
+func (c *Client) DetectText(session, text, languageCode string) (*df.DetectIntentResponse, error) {
+	req := &df.DetectIntentRequest{
+		QueryInput: &df.QueryInput{
+			Input: &df.QueryInput_Text{
+				Text: &df.TextInput{Text: text, LanguageCode: languageCode},
+			},
+		},
+	}
+	res := &df.DetectIntentResponse{}
+	if err := c.call("POST", session+":detectIntent", req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Push triggers the event in the session of a scheduled action, passing on
+// the parameters of the action. It completes asynchronous fulfillments which
+// finish after the webhook request returned.
+func (c *Client) Push(action ScheduledAction, event string) (*df.DetectIntentResponse, error) {
+	return c.DetectEvent(action.Session, event, action.Language, action.Params)
+}
+
+// call sends a request to the dialogflow API and decodes the response into res
+func (c *Client) call(method, path string, req, res proto.Message) error {
+	var body bytes.Buffer
+	if req != nil {
+		if err := (&jsonpb.Marshaler{}).Marshal(&body, req); err != nil {
+			return fmt.Errorf("unable to encode dialogflow request: %v", err)
+		}
+	}
+
+	httpReq, err := http.NewRequest(method, c.Endpoint+"/"+path, &body)
+	if err != nil {
+		return err
+	}
+	token, err := c.Tokens.Token()
+	if err != nil {
+		return fmt.Errorf("unable to get access token: %v", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpRes, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	b, err := ioutil.ReadAll(httpRes.Body)
+	if err != nil {
+		return err
+	}
+	if httpRes.StatusCode >= 300 {
+		return fmt.Errorf("dialogflow API returned %v: %s", httpRes.Status, b)
+	}
+	if res == nil || len(b) == 0 {
+		return nil
+	}
+	unmarshaler := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := unmarshaler.Unmarshal(bytes.NewReader(b), res); err != nil {
+		return fmt.Errorf("unable to decode dialogflow response: %v", err)
+	}
+	return nil
+}
+
+// CreateSessionEntityType creates a session entity type in a session
+func (c *Client) CreateSessionEntityType(session string, set *df.SessionEntityType) (*df.SessionEntityType, error) {
+	res := &df.SessionEntityType{}
+	if err := c.call("POST", session+"/entityTypes", set, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpdateSessionEntityType replaces the entities of an existing session entity type
+func (c *Client) UpdateSessionEntityType(set *df.SessionEntityType) (*df.SessionEntityType, error) {
+	res := &df.SessionEntityType{}
+	if err := c.call("PATCH", set.Name, set, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListContexts returns the active contexts of a session
+func (c *Client) ListContexts(session string) ([]*df.Context, error) {
+	var contexts []*df.Context
+	pageToken := ""
+	for {
+		path := session + "/contexts"
+		if pageToken != "" {
+			path += "?pageToken=" + url.QueryEscape(pageToken)
+		}
+		res := &df.ListContextsResponse{}
+		if err := c.call("GET", path, nil, res); err != nil {
+			return nil, err
+		}
+		contexts = append(contexts, res.Contexts...)
+		if res.NextPageToken == "" {
+			return contexts, nil
+		}
+		pageToken = res.NextPageToken
+	}
+}
@@ -0,0 +1,79 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// EnableGzip compresses response bodies larger than threshold bytes for
+// clients sending "Accept-Encoding: gzip". A threshold of 0 disables compression.
+func EnableGzip(threshold int) {
+	gzipThreshold = threshold
+}
+
+// header returns a request header, API Gateway does not normalize the case of header names
+func header(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// acceptsGzip checks the Accept-Encoding header of the request for gzip. An
+// explicit gzip entry takes precedence over "*", a q-value of 0 refuses it.
+func acceptsGzip(req events.APIGatewayProxyRequest) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, encoding := range strings.Split(header(req.Headers, "Accept-Encoding"), ",") {
+		parts := strings.Split(encoding, ";")
+		q := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) > 2 && strings.EqualFold(param[:2], "q=") {
+				parsed, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+// compressResponse gzips the response body if enabled and accepted by the client
+func compressResponse(req events.APIGatewayProxyRequest, resp *events.APIGatewayProxyResponse) error {
+	if gzipThreshold <= 0 || len(resp.Body) < gzipThreshold || !acceptsGzip(req) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(resp.Body)); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	resp.Body = base64.StdEncoding.EncodeToString(buf.Bytes())
+	resp.IsBase64Encoded = true
+	resp.Headers["Content-Encoding"] = "gzip"
+	resp.Headers["Vary"] = "Accept-Encoding"
+	return nil
+}
@@ -0,0 +1,32 @@
+package lambdadialogflow
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{"gzip", true},
+		{"deflate, gzip;q=0.8", true},
+		{"*", true},
+		{"", false},
+		{"deflate, br", false},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0, deflate", false},
+		{"gzip;q=0, *", false},
+		{"*;q=0", false},
+		{"br, *;q=0.1", true},
+		{"GZIP;Q=1", true},
+	}
+	for _, test := range tests {
+		req := events.APIGatewayProxyRequest{Headers: map[string]string{"accept-encoding": test.acceptEncoding}}
+		if got := acceptsGzip(req); got != test.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", test.acceptEncoding, got, test.want)
+		}
+	}
+}
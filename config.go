@@ -0,0 +1,139 @@
+package lambdadialogflow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LookupFunc looks up a configuration value by key, e.g. os.LookupEnv or a
+// function reading SSM parameters by path
+type LookupFunc func(key string) (string, bool)
+
+// Validator is implemented by config structs checking their own values after binding
+type Validator interface {
+	Validate() error
+}
+
+// BindConfig fills the fields of the struct v points to from the environment.
+// Fields are bound by their tags:
+//
+//	type Config struct {
+//		Table   string        `env:"ORDERS_TABLE" required:"true"`
+//		Timeout time.Duration `env:"ORDERS_TIMEOUT" default:"2s"`
+//		Key     string        `ssm:"/orders/api-key"`
+//	}
+//
+// Fields tagged with ssm are looked up with the lookup functions passed in,
+// environment variables always with os.LookupEnv. Supported field types are
+// strings, bools, numbers, durations and comma separated string slices.
+// Structs implementing Validator are validated after binding.
+func BindConfig(v interface{}, ssm ...LookupFunc) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config must be a pointer to a struct")
+	}
+	rv = rv.Elem()
+
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		value, found := "", false
+		if key := field.Tag.Get("env"); key != "" {
+			value, found = os.LookupEnv(key)
+		}
+		if key := field.Tag.Get("ssm"); key != "" && !found {
+			for _, lookup := range ssm {
+				if value, found = lookup(key); found {
+					break
+				}
+			}
+		}
+		if !found {
+			value, found = field.Tag.Lookup("default")
+		}
+		if !found {
+			if field.Tag.Get("required") == "true" {
+				return fmt.Errorf("config %v is required", field.Name)
+			}
+			continue
+		}
+		if err := setField(rv.Field(i), value); err != nil {
+			return fmt.Errorf("config %v: %v", field.Name, err)
+		}
+	}
+
+	if validator, ok := v.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// MustBindConfig binds the config like BindConfig and panics on errors, it is
+// meant for package level variables bound at cold start
+func MustBindConfig(v interface{}, ssm ...LookupFunc) {
+	if err := BindConfig(v, ssm...); err != nil {
+		panic(err)
+	}
+}
+
+// setField parses the value into the field
+func setField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %v", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items).Convert(field.Type()))
+	default:
+		return fmt.Errorf("unsupported type %v", field.Type())
+	}
+	return nil
+}
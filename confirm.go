@@ -0,0 +1,41 @@
+package lambdadialogflow
+
+// confirmContext holds the pending yes/no question and the actions answering it
+const confirmContext = "lambdadialogflow-confirm"
+
+// Confirm asks a yes/no question and routes the answer of the next turn to
+// the handler registered for yesAction or noAction. Actions are used instead
+// of handler functions since the next turn may be served by another lambda
+// instance. Unclear answers repeat the question, unless the user moved on to
+// another intent with a registered handler.
+func (w *Agent) Confirm(question, yesAction, noAction string) {
+	w.Say(question)
+	w.OutputContext(confirmContext).
+		SetLifespan(1).
+		SetParam("question", question).
+		SetParam("yes", yesAction).
+		SetParam("no", noAction)
+}
+
+// confirmation returns the handler answering a pending confirmation, or nil
+// if there is none or the user moved on
+func confirmation(w *Agent) WebhookHandler {
+	ctx := w.inputContext(confirmContext)
+	if ctx == nil {
+		return nil
+	}
+	fields := ctx.GetParameters().GetFields()
+
+	switch w.YesNo() {
+	case AnswerYes:
+		return w.router.handler(fields["yes"].GetStringValue())
+	case AnswerNo:
+		return w.router.handler(fields["no"].GetStringValue())
+	}
+	if w.router.lookup(w) != nil && !w.req.GetQueryResult().GetIntent().GetIsFallback() {
+		return nil
+	}
+	return func(w *Agent) {
+		w.Confirm(fields["question"].GetStringValue(), fields["yes"].GetStringValue(), fields["no"].GetStringValue())
+	}
+}
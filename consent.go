@@ -0,0 +1,110 @@
+package lambdadialogflow
+
+const (
+	// consentKey stores the consent of the caller in the session store
+	consentKey = "recordingConsent"
+	// consentContext keeps the consent when no session store is configured
+	consentContext = "lambdadialogflow-consent"
+	// consentPendingContext marks that the disclosure was played in the previous turn
+	consentPendingContext = "lambdadialogflow-consent-pending"
+)
+
+// ConsentFlow asks callers for consent to record the call before any other
+// handler runs. The answer is persisted in the session store, if configured,
+// and in a long living context otherwise.
+type ConsentFlow struct {
+	// Disclosure is played as long as the caller has not answered
+	Disclosure string
+	// Reprompt is played when the answer was neither yes nor no
+	Reprompt string
+	// Granted is played after the caller consented
+	Granted string
+	// Declined handles the turn after the caller declined, e.g. by transferring the call
+	Declined WebhookHandler
+	// Sources are the platforms requiring consent, defaults to the phone gateway
+	Sources []string
+}
+
+var consentFlow *ConsentFlow
+
+// RequireConsent gates all handlers behind the consent flow
+func RequireConsent(flow *ConsentFlow) {
+	consentFlow = flow
+}
+
+// Consent returns whether the caller consented and whether they answered at all
+func (w *Agent) Consent() (granted bool, answered bool) {
+	if v, ok := w.SessionValue(consentKey).(bool); ok {
+		return v, true
+	}
+	if ctx := w.inputContext(consentContext); ctx != nil {
+		return ctx.GetParameters().GetFields()["granted"].GetBoolValue(), true
+	}
+	return false, false
+}
+
+// applies reports whether the request comes from a platform requiring consent
+func (c *ConsentFlow) applies(w *Agent) bool {
+	sources := c.Sources
+	if len(sources) == 0 {
+		sources = []string{telephonySource}
+	}
+	for _, source := range sources {
+		if w.req.GetOriginalDetectIntentRequest().GetSource() == source {
+			return true
+		}
+	}
+	return false
+}
+
+// gate returns the handler running the consent flow, or nil when the regular
+// handler may run
+func (c *ConsentFlow) gate(w *Agent) WebhookHandler {
+	if c == nil || !c.applies(w) {
+		return nil
+	}
+	granted, answered := w.Consent()
+	switch {
+	case answered && granted:
+		return nil
+	case answered:
+		return c.declined
+	}
+	return c.ask
+}
+
+// ask plays the disclosure or evaluates the answer to it
+func (c *ConsentFlow) ask(w *Agent) {
+	if w.inputContext(consentPendingContext) == nil {
+		w.Say(c.Disclosure)
+		w.OutputContext(consentPendingContext).SetLifespan(1)
+		return
+	}
+
+	switch w.YesNo() {
+	case AnswerYes:
+		c.persist(w, true)
+		w.Say(c.Granted)
+	case AnswerNo:
+		c.persist(w, false)
+		c.declined(w)
+	default:
+		w.Say(c.Reprompt)
+		w.OutputContext(consentPendingContext).SetLifespan(1)
+	}
+}
+
+// declined handles turns of callers who did not consent
+func (c *ConsentFlow) declined(w *Agent) {
+	if c.Declined != nil {
+		c.Declined(w)
+	}
+}
+
+// persist stores the answer of the caller
+func (c *ConsentFlow) persist(w *Agent, granted bool) {
+	if sessionStore != nil {
+		w.SetSessionValue(consentKey, granted)
+	}
+	w.OutputContext(consentContext).SetLifespan(MaxLifespan).SetParam("granted", granted)
+}
@@ -0,0 +1,261 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// shortContextName strips the session prefix from a context resource name,
+// e.g. projects/p/agent/sessions/s/contexts/order-followup becomes order-followup
+func shortContextName(name string) string {
+	if i := strings.LastIndex(name, "/contexts/"); i >= 0 {
+		return name[i+len("/contexts/"):]
+	}
+	return name
+}
+
+// inputContext returns the active context with the given short or full name
+// from the request. Dialogflow lowercases context names, so the comparison
+// ignores case.
+func (w *Agent) inputContext(name string) *df.Context {
+	name = shortContextName(name)
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		if strings.EqualFold(shortContextName(ctx.Name), name) {
+			if expired(ctx.Parameters) {
+				return nil
+			}
+			return ctx
+		}
+	}
+	return nil
+}
+
+// contextName returns the resource name of a context, dialogflow ignores
+// output contexts named without the session
+func (w *Agent) contextName(name string) string {
+	if strings.Contains(name, "/contexts/") || w.Session() == "" {
+		return name
+	}
+	return w.Session() + "/contexts/" + name
+}
+
+// DefaultLifespan is the lifespan of output contexts created by OutputContext,
+// the same default the dialogflow console uses
+const DefaultLifespan = 5
+
+// OutputContext is a context of the response which can be modified by several
+// middlewares and the handler without overwriting each other
+type OutputContext struct {
+	ctx *df.Context
+}
+
+// OutputContext returns the output context with the given short or full name,
+// creating it with the DefaultLifespan if it does not exist yet
+func (w *Agent) OutputContext(name string) *OutputContext {
+	for _, ctx := range w.res.OutputContexts {
+		if strings.EqualFold(shortContextName(ctx.Name), shortContextName(name)) {
+			return &OutputContext{ctx: ctx}
+		}
+	}
+	ctx := &df.Context{Name: w.contextName(name), LifespanCount: DefaultLifespan}
+	w.res.OutputContexts = append(w.res.OutputContexts, ctx)
+	return &OutputContext{ctx: ctx}
+}
+
+// Name returns the name of the context
+func (c *OutputContext) Name() string {
+	return c.ctx.Name
+}
+
+// Lifespan returns the number of turns the context stays active
+func (c *OutputContext) Lifespan() int32 {
+	return c.ctx.LifespanCount
+}
+
+// SetLifespan sets the number of turns the context stays active
+func (c *OutputContext) SetLifespan(lifespan int32) *OutputContext {
+	c.ctx.LifespanCount = lifespan
+	return c
+}
+
+// Param returns a parameter of the context
+func (c *OutputContext) Param(name string) *_structpb.Value {
+	return c.ctx.GetParameters().GetFields()[name]
+}
+
+// SetParam adds a parameter to the context, keeping all other parameters
+func (c *OutputContext) SetParam(name string, value interface{}) *OutputContext {
+	if c.ctx.Parameters == nil || c.ctx.Parameters.Fields == nil {
+		c.ctx.Parameters = &_structpb.Struct{Fields: map[string]*_structpb.Value{}}
+	}
+	c.ctx.Parameters.Fields[name] = toValue(value)
+	return c
+}
+
+// MaxLifespan is the largest lifespan accepted by SetContexts. Contexts expire
+// after 20 minutes without a matching query anyway, so larger values are most
+// likely a mistake.
+const MaxLifespan = 100
+
+// ContextSpec describes an output context to be set by SetContexts
+type ContextSpec struct {
+	Name     string
+	Lifespan int32
+	Params   map[string]interface{}
+}
+
+// SetContexts validates and applies a set of output contexts. Either all
+// contexts are applied or, if one of them is invalid, none.
+func (w *Agent) SetContexts(specs []ContextSpec) error {
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		name := strings.ToLower(shortContextName(spec.Name))
+		if err := validateContextName(name); err != nil {
+			return err
+		}
+		if seen[name] {
+			return fmt.Errorf("context %v is set twice", spec.Name)
+		}
+		seen[name] = true
+		if spec.Lifespan < 0 || spec.Lifespan > MaxLifespan {
+			return fmt.Errorf("lifespan %v of context %v is not between 0 and %v", spec.Lifespan, spec.Name, MaxLifespan)
+		}
+	}
+
+	for _, spec := range specs {
+		ctx := w.OutputContext(spec.Name).SetLifespan(spec.Lifespan)
+		for name, value := range spec.Params {
+			ctx.SetParam(name, value)
+		}
+	}
+	return nil
+}
+
+// ClearContexts deactivates the given contexts by setting their lifespan to 0
+func (w *Agent) ClearContexts(names ...string) {
+	for _, name := range names {
+		w.OutputContext(name).SetLifespan(0)
+	}
+}
+
+// SetContextParams attaches parameters to an output context, keeping its
+// lifespan. The parameters are a map or a struct, encoded like encoding/json.
+func (w *Agent) SetContextParams(name string, params interface{}) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("unable to encode context parameters: %v", err)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("context parameters must be an object: %v", err)
+	}
+	ctx := w.OutputContext(name)
+	for key, value := range values {
+		ctx.SetParam(key, value)
+	}
+	return nil
+}
+
+// ClearContext deactivates a context by setting its lifespan to 0
+func (w *Agent) ClearContext(name string) {
+	w.ClearContexts(name)
+}
+
+// ClearAllContexts deactivates all active contexts and the output contexts
+// set so far
+func (w *Agent) ClearAllContexts() {
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		w.OutputContext(ctx.Name).SetLifespan(0)
+	}
+	for _, ctx := range w.res.OutputContexts {
+		ctx.LifespanCount = 0
+	}
+}
+
+// validateContextName checks a context id against the rules of dialogflow
+func validateContextName(name string) error {
+	if name == "" || len(name) > 250 {
+		return fmt.Errorf("context name %q must be between 1 and 250 bytes long", name)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '%') {
+			return fmt.Errorf("context name %q contains invalid character %q", name, r)
+		}
+	}
+	return nil
+}
+
+// InputContext is an active context of the request
+type InputContext struct {
+	ctx *df.Context
+}
+
+// GetContext returns the active context with the given short or full name,
+// or nil if it is not active
+func (w *Agent) GetContext(name string) *InputContext {
+	if ctx := w.inputContext(name); ctx != nil {
+		return &InputContext{ctx: ctx}
+	}
+	return nil
+}
+
+// HasContext reports whether the context with the given short or full name is active
+func (w *Agent) HasContext(name string) bool {
+	return w.inputContext(name) != nil
+}
+
+// Contexts returns all active contexts of the request
+func (w *Agent) Contexts() []*InputContext {
+	var contexts []*InputContext
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		if expired(ctx.Parameters) {
+			continue
+		}
+		contexts = append(contexts, &InputContext{ctx: ctx})
+	}
+	return contexts
+}
+
+// Name returns the short name of the context
+func (c *InputContext) Name() string {
+	return shortContextName(c.ctx.Name)
+}
+
+// FullName returns the resource name of the context including the session
+func (c *InputContext) FullName() string {
+	return c.ctx.Name
+}
+
+// Lifespan returns the number of turns the context stays active
+func (c *InputContext) Lifespan() int32 {
+	return c.ctx.LifespanCount
+}
+
+// Params returns all parameters of the context
+func (c *InputContext) Params() map[string]interface{} {
+	return fromStruct(c.ctx.Parameters)
+}
+
+// Param returns a parameter of the context
+func (c *InputContext) Param(name string) *_structpb.Value {
+	return c.ctx.GetParameters().GetFields()[name]
+}
+
+// StringParam returns a string parameter of the context
+func (c *InputContext) StringParam(name string) string {
+	return c.Param(name).GetStringValue()
+}
+
+// NumberParam returns a number parameter of the context
+func (c *InputContext) NumberParam(name string) float64 {
+	return c.Param(name).GetNumberValue()
+}
+
+// BoolParam returns a boolean parameter of the context
+func (c *InputContext) BoolParam(name string) bool {
+	return c.Param(name).GetBoolValue()
+}
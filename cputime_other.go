@@ -0,0 +1,10 @@
+//go:build !unix
+
+package lambdadialogflow
+
+import "time"
+
+// cpuTime is not measured on platforms without getrusage
+func cpuTime() time.Duration {
+	return 0
+}
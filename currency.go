@@ -0,0 +1,130 @@
+package lambdadialogflow
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// numberFormat describes how a locale writes monetary amounts
+type numberFormat struct {
+	decimal      string
+	group        string
+	symbolBefore bool
+	space        bool
+}
+
+// numberFormats maps language codes, with or without region, onto their number format
+var numberFormats = map[string]numberFormat{
+	"en":    {decimal: ".", group: ",", symbolBefore: true},
+	"ja":    {decimal: ".", group: ",", symbolBefore: true},
+	"zh":    {decimal: ".", group: ",", symbolBefore: true},
+	"ko":    {decimal: ".", group: ",", symbolBefore: true},
+	"de":    {decimal: ",", group: ".", space: true},
+	"de-ch": {decimal: ".", group: "'", symbolBefore: true, space: true},
+	"es":    {decimal: ",", group: ".", space: true},
+	"it":    {decimal: ",", group: ".", space: true},
+	"fr":    {decimal: ",", group: " ", space: true},
+	"nl":    {decimal: ",", group: ".", symbolBefore: true, space: true},
+	"pt":    {decimal: ",", group: ".", space: true},
+	"pt-br": {decimal: ",", group: ".", symbolBefore: true, space: true},
+	"da":    {decimal: ",", group: ".", space: true},
+	"sv":    {decimal: ",", group: " ", space: true},
+	"no":    {decimal: ",", group: " ", space: true},
+	"ru":    {decimal: ",", group: " ", space: true},
+	"pl":    {decimal: ",", group: " ", space: true},
+}
+
+// currencySymbols maps ISO 4217 codes onto their symbols, other codes are written as is
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "¥",
+	"INR": "₹",
+	"KRW": "₩",
+	"BRL": "R$",
+	"RUB": "₽",
+	"PLN": "zł",
+}
+
+// currencyDecimals lists the currencies without minor units, all others use two decimals
+var currencyDecimals = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"CLP": 0,
+	"ISK": 0,
+}
+
+// FormatCurrency formats an amount of the ISO 4217 currency for the locale,
+// e.g. "$1,234.50" for en-US or "1.234,50 €" for de-DE
+func FormatCurrency(amount float64, currency, locale string) string {
+	currency = strings.ToUpper(currency)
+	format, ok := numberFormats[strings.ToLower(locale)]
+	if !ok {
+		format, ok = numberFormats[strings.ToLower(strings.SplitN(locale, "-", 2)[0])]
+	}
+	if !ok {
+		format = numberFormats["en"]
+	}
+
+	decimals, ok := currencyDecimals[currency]
+	if !ok {
+		decimals = 2
+	}
+	number := formatNumber(math.Abs(amount), decimals, format)
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		symbol = currency
+		format.space = true
+	}
+	separator := ""
+	if format.space {
+		separator = " "
+	}
+	// the sign goes first, e.g. "-£5.00" and "-5,00 €"
+	if format.symbolBefore {
+		return sign + symbol + separator + number
+	}
+	return sign + number + separator + symbol
+}
+
+// FormatCurrency formats an amount of the currency in the language of the request
+func (w *Agent) FormatCurrency(amount float64, currency string) string {
+	return FormatCurrency(amount, currency, w.req.GetQueryResult().GetLanguageCode())
+}
+
+// formatNumber writes a positive number with grouped thousands
+func formatNumber(amount float64, decimals int, format numberFormat) string {
+	s := strconv.FormatFloat(amount, 'f', decimals, 64)
+	integer, fraction := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		integer, fraction = s[:i], s[i+1:]
+	}
+
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(format.group)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(format.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// GetCurrencyParam returns the amount and ISO 4217 currency code of a
+// @sys.unit-currency parameter
+func (w *Agent) GetCurrencyParam(name string) (float64, string) {
+	fields := w.getField(name).GetStructValue().GetFields()
+	return fields["amount"].GetNumberValue(), fields["currency"].GetStringValue()
+}
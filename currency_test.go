@@ -0,0 +1,46 @@
+package lambdadialogflow
+
+import "testing"
+
+func TestFormatCurrency(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		locale   string
+		want     string
+	}{
+		{1234.5, "USD", "en-US", "$1,234.50"},
+		{1234.5, "EUR", "de-DE", "1.234,50\u00a0€"},
+		{1234.5, "CHF", "de-CH", "CHF\u00a01'234.50"},
+		{1234567.891, "eur", "fr", "1\u202f234\u202f567,89\u00a0€"},
+		{1234, "JPY", "ja", "¥1,234"},
+		{-5, "GBP", "en-GB", "-£5.00"},
+		{-5, "EUR", "de", "-5,00\u00a0€"},
+		{99.99, "SEK", "sv", "99,99\u00a0SEK"},
+		{10, "USD", "xx", "$10.00"},
+		{0.5, "BRL", "pt-BR", "R$\u00a00,50"},
+	}
+	for _, test := range tests {
+		if got := FormatCurrency(test.amount, test.currency, test.locale); got != test.want {
+			t.Errorf("FormatCurrency(%v, %v, %v) = %q, want %q", test.amount, test.currency, test.locale, got, test.want)
+		}
+	}
+}
+
+func TestGetCurrencyParam(t *testing.T) {
+	tests := []struct {
+		param    string
+		amount   float64
+		currency string
+	}{
+		{`{"amount": 12.5, "currency": "EUR"}`, 12.5, "EUR"},
+		{`{"amount": 3}`, 3, ""},
+		{`"12 euros"`, 0, ""},
+	}
+	for _, test := range tests {
+		w := testAgent(t, `{"parameters":{"price":`+test.param+`}}`)
+		if amount, currency := w.GetCurrencyParam("price"); amount != test.amount || currency != test.currency {
+			t.Errorf("GetCurrencyParam(%v) = %v, %v, want %v, %v", test.param, amount, currency, test.amount, test.currency)
+		}
+	}
+}
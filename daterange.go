@@ -0,0 +1,82 @@
+package lambdadialogflow
+
+import (
+	"strings"
+	"time"
+)
+
+// dateRangeContext holds the state of a date range being collected
+const dateRangeContext = "lambdadialogflow-daterange"
+
+// DateRangeFlow collects a start and an end date across turns. Relative
+// expressions like "next weekend" are resolved by the @sys.date and
+// @sys.date-period entities of dialogflow; the flow validates the order of the
+// dates and keeps the start date until the end date is known.
+type DateRangeFlow struct {
+	// Action of the owning handler, receiving the turns until the range is complete
+	Action string
+	// DateParam and PeriodParam default to "date" and "date-period"
+	DateParam   string
+	PeriodParam string
+	AskStart    string
+	AskEnd      string
+	// Invalid is said when the end date lies before the start date
+	Invalid string
+}
+
+// Collect returns the date range once it is complete. Otherwise it asks for
+// the missing date and returns false.
+func (f DateRangeFlow) Collect(w *Agent) (Period, bool) {
+	dateParam, periodParam := f.DateParam, f.PeriodParam
+	if dateParam == "" {
+		dateParam = "date"
+	}
+	if periodParam == "" {
+		periodParam = "date-period"
+	}
+
+	var start time.Time
+	if ctx := w.inputContext(dateRangeContext); ctx != nil {
+		if s := ctx.GetParameters().GetFields()["start"].GetStringValue(); s != "" {
+			start, _ = w.parseTime(s)
+		}
+	}
+
+	if p, err := w.GetPeriodParam(periodParam); err == nil {
+		if p.End.Before(p.Start) {
+			return f.ask(w, time.Time{}, f.Invalid+" "+f.AskStart)
+		}
+		w.ClearContexts(dateRangeContext)
+		return p, true
+	}
+
+	date, err := w.parseTime(w.GetStringParam(dateParam))
+	switch {
+	case err != nil && start.IsZero():
+		return f.ask(w, start, f.AskStart)
+	case err != nil:
+		return f.ask(w, start, f.AskEnd)
+	case start.IsZero():
+		return f.ask(w, date, f.AskEnd)
+	case date.Before(start):
+		return f.ask(w, start, f.Invalid+" "+f.AskEnd)
+	}
+	w.ClearContexts(dateRangeContext)
+	return Period{Start: start, End: date}, true
+}
+
+// ask prompts for the next date, keeping the start date collected so far
+func (f DateRangeFlow) ask(w *Agent, start time.Time, text string) (Period, bool) {
+	w.Say(strings.TrimSpace(text))
+	ctx := w.OutputContext(dateRangeContext).SetLifespan(DefaultLifespan).SetParam("action", f.Action)
+	if !start.IsZero() {
+		ctx.SetParam("start", start.Format(time.RFC3339))
+	}
+	return Period{}, false
+}
+
+// dateRange returns the handler owning a date range being collected, or nil if
+// there is none or the user moved on to another intent with a registered handler
+func dateRange(w *Agent) WebhookHandler {
+	return resume(w, dateRangeContext)
+}
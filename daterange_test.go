@@ -0,0 +1,47 @@
+package lambdadialogflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateRangeFlow(t *testing.T) {
+	var collected Period
+	flow := DateRangeFlow{Action: "trip.dates", AskStart: "From when?", AskEnd: "Until when?", Invalid: "That is before the start."}
+	r := NewRouter()
+	r.Register("trip.dates", func(w *Agent) {
+		if p, ok := flow.Collect(w); ok {
+			collected = p
+			w.Say("Done.")
+		}
+	})
+	c := newConversation(t, r)
+
+	turns := []struct {
+		action string
+		params map[string]interface{}
+		want   string
+	}{
+		{"trip.dates", nil, "From when?"},
+		{"", map[string]interface{}{"date": "2020-05-10"}, "Until when?"},
+		{"", map[string]interface{}{"date": "2020-05-01"}, "That is before the start. Until when?"},
+		{"", map[string]interface{}{"date": "2020-05-12"}, "Done."},
+	}
+	for i, turn := range turns {
+		if res := c.say(turn.action, "", turn.params); res.FulfillmentText != turn.want {
+			t.Fatalf("turn %v: %q, want %q", i+1, res.FulfillmentText, turn.want)
+		}
+	}
+	want := Period{time.Date(2020, 5, 10, 0, 0, 0, 0, time.UTC), time.Date(2020, 5, 12, 0, 0, 0, 0, time.UTC)}
+	if !collected.Start.Equal(want.Start) || !collected.End.Equal(want.End) {
+		t.Errorf("collected %v, want %v", collected, want)
+	}
+
+	// a period fills both dates at once
+	res := newConversation(t, r).say("trip.dates", "", map[string]interface{}{
+		"date-period": map[string]interface{}{"startDate": "2020-06-01", "endDate": "2020-06-07"},
+	})
+	if res.FulfillmentText != "Done." || !collected.End.Equal(time.Date(2020, 6, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("period: %q, collected %v", res.FulfillmentText, collected)
+	}
+}
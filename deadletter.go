@@ -0,0 +1,55 @@
+package lambdadialogflow
+
+import (
+	"log"
+	"time"
+)
+
+// DeadLetter describes a turn which could not be handled
+type DeadLetter struct {
+	Request string     `json:"request"`
+	Action  string     `json:"action"`
+	Session string     `json:"session"`
+	Class   ErrorClass `json:"class"`
+	Error   string     `json:"error"`
+	Time    time.Time  `json:"time"`
+}
+
+// DeadLetterQueue receives failed turns for later inspection and replay. The
+// aws package provides a queue sending the dead letters to SQS.
+type DeadLetterQueue interface {
+	Send(letter DeadLetter) error
+}
+
+var (
+	deadLetterQueue   DeadLetterQueue
+	deadLetterClasses = map[ErrorClass]bool{ErrorInternal: true, ErrorHandlerFailed: true, ErrorPanic: true, ErrorTimeout: true}
+)
+
+// SetDeadLetterQueue sends the original request of failed turns to the queue
+func SetDeadLetterQueue(q DeadLetterQueue) {
+	deadLetterQueue = q
+}
+
+// sendDeadLetter passes the failed turn on to the dead letter queue, if configured
+func sendDeadLetter(body string, w *Agent, class ErrorClass, err error) {
+	if deadLetterQueue == nil || !deadLetterClasses[class] {
+		return
+	}
+	letter := DeadLetter{
+		Request: body,
+		Action:  w.Action(),
+		Session: w.Session(),
+		Class:   class,
+		Error:   err.Error(),
+		Time:    time.Now(),
+	}
+	if w.capture("deadLetter", letter) {
+		// failed turns are answered with an http error, so the report is logged
+		logDryRun("dead letter", letter)
+		return
+	}
+	if err := deadLetterQueue.Send(letter); err != nil {
+		log.Printf("unable to send dead letter: %v", err)
+	}
+}
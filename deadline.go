@@ -0,0 +1,43 @@
+package lambdadialogflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ContextHandler handles one dialogflow request, receiving a context carrying
+// the deadline of the lambda invocation for downstream calls
+type ContextHandler func(context.Context, *Agent)
+
+// WithContext adapts a ContextHandler to be registered like any other handler
+func WithContext(handler ContextHandler) WebhookHandler {
+	return func(w *Agent) {
+		handler(w.Context(), w)
+	}
+}
+
+// Context returns the context of the request. It is cancelled when the lambda
+// invocation times out or the http client goes away.
+func (w *Agent) Context() context.Context {
+	if w.ctx == nil {
+		return context.Background()
+	}
+	return w.ctx
+}
+
+// deadlineExceeded returns an error if the handler ran past the deadline of
+// the request, dialogflow has given up on the answer by then
+func (w *Agent) deadlineExceeded() error {
+	if w.ctx == nil || w.ctx.Err() != context.DeadlineExceeded {
+		return nil
+	}
+	return fmt.Errorf("handler for action %v exceeded the deadline", w.Action())
+}
+
+// HandleRequestContext handles the dialogflow request like HandleRequest,
+// passing ctx on to the handlers
+func HandleRequestContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return defaultRouter.ServeContext(ctx, req)
+}
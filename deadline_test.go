@@ -0,0 +1,41 @@
+package lambdadialogflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// recordingQueue is a DeadLetterQueue keeping the dead letters
+type recordingQueue struct {
+	letters []DeadLetter
+}
+
+func (q *recordingQueue) Send(letter DeadLetter) error {
+	q.letters = append(q.letters, letter)
+	return nil
+}
+
+func TestDeadlineExceeded(t *testing.T) {
+	queue := &recordingQueue{}
+	SetDeadLetterQueue(queue)
+	defer SetDeadLetterQueue(nil)
+
+	r := NewRouter()
+	r.Register("slow", WithContext(func(ctx context.Context, w *Agent) {
+		<-ctx.Done()
+		w.Say("too late")
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	body := `{"responseId":"1","session":"` + testSession + `","queryResult":{"action":"slow"}}`
+	resp, err := r.ServeContext(ctx, events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: body})
+	if err == nil || resp.StatusCode != 504 {
+		t.Errorf("status %v, error %v", resp.StatusCode, err)
+	}
+	if len(queue.letters) != 1 || queue.letters[0].Class != ErrorTimeout || queue.letters[0].Request != body {
+		t.Errorf("dead letters = %+v", queue.letters)
+	}
+}
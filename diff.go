@@ -0,0 +1,118 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// ResponseDiff is a difference between the responses of two routers
+type ResponseDiff struct {
+	// Request is the index of the recorded request
+	Request int
+	// Field is the part of the response, e.g. "fulfillmentText",
+	// "outputContexts.order" or "payload.google"
+	Field string
+	Old   string
+	New   string
+}
+
+// DiffRouters serves the recorded webhook requests with both routers and
+// reports where their responses differ, e.g. to verify a refactoring of
+// handlers. The requests run dry, so no side effects are executed. Errors of
+// serving a request are compared as "error". Time dependent parts, the
+// captured side effects and the expiry of contexts, are left out.
+func DiffRouters(before, after *Router, requests [][]byte) ([]ResponseDiff, error) {
+	var diffs []ResponseDiff
+	for i, body := range requests {
+		req := events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: string(body)}
+		opts := serveOptions{dryRun: true, recorded: true}
+		oldResp, oldErr := before.serve(context.Background(), req, opts)
+		newResp, newErr := after.serve(context.Background(), req, opts)
+
+		oldFields, err := responseFields(oldResp, oldErr)
+		if err != nil {
+			return nil, fmt.Errorf("request %v: %v", i, err)
+		}
+		newFields, err := responseFields(newResp, newErr)
+		if err != nil {
+			return nil, fmt.Errorf("request %v: %v", i, err)
+		}
+
+		var names []string
+		for name := range oldFields {
+			names = append(names, name)
+		}
+		for name := range newFields {
+			if _, ok := oldFields[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if oldFields[name] != newFields[name] {
+				diffs = append(diffs, ResponseDiff{Request: i, Field: name, Old: oldFields[name], New: newFields[name]})
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// responseFields flattens a response into the fields compared by DiffRouters.
+// Contexts are keyed by their short name, so their order does not matter.
+func responseFields(resp events.APIGatewayProxyResponse, serveErr error) (map[string]string, error) {
+	fields := map[string]string{"status": strconv.Itoa(resp.StatusCode)}
+	if serveErr != nil {
+		fields["error"] = serveErr.Error()
+	}
+	if resp.StatusCode != 200 || resp.Body == "" {
+		return fields, nil
+	}
+	res := &df.WebhookResponse{}
+	unmarshaler := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := unmarshaler.Unmarshal(bytes.NewReader([]byte(resp.Body)), res); err != nil {
+		return nil, fmt.Errorf("unable to decode response: %v", err)
+	}
+
+	fields["fulfillmentText"] = res.FulfillmentText
+	for i, msg := range res.FulfillmentMessages {
+		fields["fulfillmentMessages."+strconv.Itoa(i)] = marshalField(msg)
+	}
+	for _, ctx := range res.OutputContexts {
+		if params := ctx.GetParameters().GetFields(); params[expiresParam] != nil {
+			ctx = proto.Clone(ctx).(*df.Context)
+			delete(ctx.Parameters.Fields, expiresParam)
+		}
+		fields["outputContexts."+shortContextName(ctx.Name)] = marshalField(ctx)
+	}
+	for key, value := range fromStruct(res.Payload) {
+		if key == dryRunPayloadKey {
+			continue
+		}
+		b, _ := json.Marshal(value)
+		fields["payload."+key] = string(b)
+	}
+	if res.FollowupEventInput != nil {
+		fields["followupEventInput"] = marshalField(res.FollowupEventInput)
+	}
+	for _, entities := range res.SessionEntityTypes {
+		name := entities.Name[strings.LastIndex(entities.Name, "/")+1:]
+		fields["sessionEntityTypes."+name] = marshalField(entities)
+	}
+	return fields, nil
+}
+
+// marshalField encodes a part of the response for comparison
+func marshalField(msg proto.Message) string {
+	s, _ := (&jsonpb.Marshaler{}).MarshalToString(msg)
+	return s
+}
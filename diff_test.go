@@ -0,0 +1,50 @@
+package lambdadialogflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffRouters(t *testing.T) {
+	SetStateTTL(time.Hour)
+	defer SetStateTTL(0)
+
+	before, after := NewRouter(), NewRouter()
+	before.Register("greet", func(w *Agent) {
+		w.Say("hello")
+		w.SetContext("lambdadialogflow-greeted", 2)
+	})
+	after.Register("greet", func(w *Agent) {
+		w.Say("hi")
+		w.SetContext("lambdadialogflow-greeted", 2)
+	})
+	before.Register("order", func(w *Agent) {
+		w.Say("ordered")
+	})
+
+	requests := [][]byte{
+		[]byte(`{"responseId":"1","session":"projects/p/agent/sessions/s","queryResult":{"action":"greet"}}`),
+		[]byte(`{"responseId":"2","session":"projects/p/agent/sessions/s","queryResult":{"action":"order"}}`),
+	}
+	diffs, err := DiffRouters(before, after, requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[int]map[string]ResponseDiff{0: {}, 1: {}}
+	for _, diff := range diffs {
+		fields[diff.Request][diff.Field] = diff
+	}
+	// the expiry stamped into the context is left out
+	if len(fields[0]) != 1 {
+		t.Errorf("diffs of request 0 = %+v, want fulfillmentText only", fields[0])
+	}
+	if d := fields[0]["fulfillmentText"]; d.Old != "hello" || d.New != "hi" {
+		t.Errorf("fulfillmentText diff = %+v", d)
+	}
+	if d := fields[1]["error"]; d.Old != "" || d.New == "" {
+		t.Errorf("error diff = %+v", d)
+	}
+	if d := fields[1]["status"]; d.Old != "200" || d.New != "404" {
+		t.Errorf("status diff = %+v", d)
+	}
+}
@@ -0,0 +1,165 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// DryRunMode decides which requests run without side effects
+type DryRunMode int
+
+const (
+	// DryRunOff executes all side effects
+	DryRunOff DryRunMode = iota
+	// DryRunOnHeader runs requests carrying a true DryRunHeader dry
+	DryRunOnHeader
+	// DryRunAlways runs all requests dry
+	DryRunAlways
+)
+
+// DryRunHeader is the header requesting a dry run in DryRunOnHeader mode
+const DryRunHeader = "X-Dry-Run"
+
+// dryRunPayloadKey is the payload field reporting the captured side effects
+const dryRunPayloadKey = "dryRun"
+
+// SideEffect is a side effect captured during a dry run
+type SideEffect struct {
+	Kind   string      `json:"kind"`
+	Detail interface{} `json:"detail"`
+}
+
+var dryRunMode DryRunMode
+
+// SetDryRun sets which requests run dry. Handlers of dry runs are executed,
+// but session store writes, dead letters, scheduled actions, conversation log
+// entries, analytics events and calls of clients obtained by Client.For are
+// captured and reported in the dryRun field of the response payload instead.
+// Session store writes are reported by their keys only. Dry runs pass the
+// replay protection like any other request.
+func SetDryRun(mode DryRunMode) {
+	dryRunMode = mode
+}
+
+// DryRun reports whether the current request runs dry
+func (w *Agent) DryRun() bool {
+	return w.dryRun
+}
+
+// dryRunRequested reports whether the request asks for a dry run
+func dryRunRequested(headers map[string]string) bool {
+	switch dryRunMode {
+	case DryRunAlways:
+		return true
+	case DryRunOnHeader:
+		dry, _ := strconv.ParseBool(header(headers, DryRunHeader))
+		return dry
+	}
+	return false
+}
+
+// capture records a side effect if the request runs dry, it returns false if
+// the side effect has to be executed
+func (w *Agent) capture(kind string, detail interface{}) bool {
+	if !w.dryRun {
+		return false
+	}
+	// round trip through JSON, so structs are reported with their fields
+	var v interface{}
+	if b, err := json.Marshal(detail); err == nil && json.Unmarshal(b, &v) == nil {
+		detail = v
+	}
+	w.sideEffects = append(w.sideEffects, SideEffect{Kind: kind, Detail: detail})
+	return true
+}
+
+// reportSideEffects adds the captured side effects to the response payload
+func (w *Agent) reportSideEffects() {
+	if !w.dryRun {
+		return
+	}
+	effects := make([]interface{}, len(w.sideEffects))
+	for i, effect := range w.sideEffects {
+		effects[i] = map[string]interface{}{"kind": effect.Kind, "detail": effect.Detail}
+	}
+	w.addPayloadValue(dryRunPayloadKey, toValue(effects))
+}
+
+// For returns the client to use within the handler of w, counting its calls
+// for AccountUsage. In dry runs its calls are captured and answered with an
+// empty response.
+func (c *Client) For(w *Agent) *Client {
+	if !w.dryRun {
+		var httpClient http.Client
+		if c.HTTPClient != nil {
+			httpClient = *c.HTTPClient
+		}
+		httpClient.Transport = w.Transport(httpClient.Transport)
+		return &Client{HTTPClient: &httpClient, Tokens: c.Tokens, Endpoint: c.Endpoint}
+	}
+	return &Client{
+		HTTPClient: &http.Client{Transport: captureTransport{w: w}},
+		Tokens:     dryRunTokens{},
+		Endpoint:   c.Endpoint,
+	}
+}
+
+// httpClient returns a copy of base whose requests are counted for
+// AccountUsage, or captured in dry runs
+func (w *Agent) httpClient(base *http.Client) *http.Client {
+	var httpClient http.Client
+	if base != nil {
+		httpClient = *base
+	}
+	if w.dryRun {
+		httpClient.Transport = captureTransport{w: w}
+	} else {
+		httpClient.Transport = w.Transport(httpClient.Transport)
+	}
+	return &httpClient
+}
+
+// captureTransport captures http requests instead of sending them
+type captureTransport struct {
+	w *Agent
+}
+
+// RoundTrip records the request and returns an empty JSON object
+func (t captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body interface{}
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if json.Unmarshal(b, &body) != nil {
+			body = string(b)
+		}
+	}
+	t.w.capture("http", map[string]interface{}{"method": req.Method, "url": req.URL.String(), "body": body})
+	return &http.Response{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		Request:    req,
+	}, nil
+}
+
+// dryRunTokens avoids fetching access tokens during dry runs
+type dryRunTokens struct{}
+
+// Token returns a placeholder token
+func (dryRunTokens) Token() (string, error) {
+	return "dry-run", nil
+}
+
+// logDryRun logs a side effect captured outside of a successful response
+func logDryRun(kind string, detail interface{}) {
+	b, _ := json.Marshal(detail)
+	log.Printf("dry run, skipped %v: %s", kind, b)
+}
@@ -0,0 +1,47 @@
+package lambdadialogflow
+
+import (
+	"sort"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// SetSessionEntities overrides the entity type with the given display name for
+// the current session. Entries map entity values to their synonyms, e.g. the
+// contact names of the user.
+func (w *Agent) SetSessionEntities(entityType string, entries map[string][]string) {
+	w.setSessionEntities(entityType, entries, df.SessionEntityType_ENTITY_OVERRIDE_MODE_OVERRIDE)
+}
+
+// AddSessionEntities extends the entity type with the given display name for the current session
+func (w *Agent) AddSessionEntities(entityType string, entries map[string][]string) {
+	w.setSessionEntities(entityType, entries, df.SessionEntityType_ENTITY_OVERRIDE_MODE_SUPPLEMENT)
+}
+
+func (w *Agent) setSessionEntities(entityType string, entries map[string][]string, mode df.SessionEntityType_EntityOverrideMode) {
+	values := make([]string, 0, len(entries))
+	for value := range entries {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	set := &df.SessionEntityType{
+		Name:               w.Session() + "/entityTypes/" + entityType,
+		EntityOverrideMode: mode,
+	}
+	for _, value := range values {
+		synonyms := entries[value]
+		if len(synonyms) == 0 {
+			synonyms = []string{value}
+		}
+		set.Entities = append(set.Entities, &df.EntityType_Entity{Value: value, Synonyms: synonyms})
+	}
+
+	for i, existing := range w.res.SessionEntityTypes {
+		if existing.Name == set.Name {
+			w.res.SessionEntityTypes[i] = set
+			return
+		}
+	}
+	w.res.SessionEntityTypes = append(w.res.SessionEntityTypes, set)
+}
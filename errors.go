@@ -0,0 +1,146 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// ErrorClass classifies the errors that can occur while handling a request
+type ErrorClass string
+
+// Error classes handled by the ErrorPolicy
+const (
+	ErrorNoHandler     ErrorClass = "no_handler"
+	ErrorInternal      ErrorClass = "internal"
+	ErrorHandlerFailed ErrorClass = "handler"
+	ErrorPanic         ErrorClass = "panic"
+	ErrorTimeout       ErrorClass = "timeout"
+)
+
+// ErrorMode decides how an error is presented to the user
+type ErrorMode int
+
+const (
+	// ErrorFallback returns an http error, so dialogflow uses the static responses of the intent
+	ErrorFallback ErrorMode = iota
+	// ErrorText replies with the text of the rule as fulfillment text
+	ErrorText
+	// ErrorSpeech replies with the text of the rule as fulfillment text and
+	// as spoken simple response on actions on google
+	ErrorSpeech
+)
+
+// ErrorRule defines how one kind of error is presented to the user
+type ErrorRule struct {
+	Mode ErrorMode
+	Text string
+}
+
+// ErrorPolicy decides per error class and platform how errors are presented
+type ErrorPolicy struct {
+	defaultRule ErrorRule
+	rules       map[string]ErrorRule
+}
+
+// NewErrorPolicy creates a policy applying defaultRule to all errors without a more specific rule
+func NewErrorPolicy(defaultRule ErrorRule) *ErrorPolicy {
+	return &ErrorPolicy{defaultRule: defaultRule, rules: make(map[string]ErrorRule)}
+}
+
+// Set adds a rule for an error class on a platform, the Source of the request
+// like "google". An empty platform applies to all platforms.
+func (p *ErrorPolicy) Set(class ErrorClass, platform string, rule ErrorRule) *ErrorPolicy {
+	p.rules[string(class)+"/"+platform] = rule
+	return p
+}
+
+// Rule returns the rule for an error class on a platform
+func (p *ErrorPolicy) Rule(class ErrorClass, platform string) ErrorRule {
+	if rule, ok := p.rules[string(class)+"/"+platform]; ok {
+		return rule
+	}
+	if rule, ok := p.rules[string(class)+"/"]; ok {
+		return rule
+	}
+	return p.defaultRule
+}
+
+// SetErrorPolicy sets the policy used to present errors to the user. Without
+// a policy all errors fall back to dialogflow's static responses.
+func SetErrorPolicy(policy *ErrorPolicy) {
+	errorPolicy = policy
+}
+
+// retryableClasses are the error classes a client may retry
+var retryableClasses = map[ErrorClass]bool{ErrorInternal: true}
+
+// SetErrorPayload attaches a structured error object with code, retryable flag
+// and correlation id to the response payload under key, so custom frontends
+// can render error states. An empty key disables the error payload.
+func SetErrorPayload(key string) {
+	errorPayloadKey = key
+}
+
+// errorPayload returns the structured error object for an error class
+func errorPayload(req events.APIGatewayProxyRequest, w *Agent, class ErrorClass) map[string]interface{} {
+	correlationID := w.req.GetResponseId()
+	if correlationID == "" {
+		correlationID = req.RequestContext.RequestID
+	}
+	return map[string]interface{}{
+		"code":          string(class),
+		"retryable":     retryableClasses[class],
+		"correlationId": correlationID,
+	}
+}
+
+// logFailure writes a structured log entry for a failed turn
+func logFailure(w *Agent, class ErrorClass, err error) {
+	entry, _ := json.Marshal(map[string]string{
+		"level":   "error",
+		"class":   string(class),
+		"action":  w.Action(),
+		"session": w.Session(),
+		"error":   err.Error(),
+	})
+	log.Printf("%s", entry)
+}
+
+// handleError presents the error according to the error policy
+func handleError(req events.APIGatewayProxyRequest, w *Agent, class ErrorClass, status int, err error) (events.APIGatewayProxyResponse, error) {
+	w.failure = class
+	logFailure(w, class, err)
+	sendDeadLetter(req.Body, w, class, err)
+	rule := ErrorRule{}
+	if errorPolicy != nil {
+		rule = errorPolicy.Rule(class, string(w.Source()))
+	}
+	if rule.Mode == ErrorFallback {
+		if errorPayloadKey == "" {
+			w.reportUsage(nil)
+			return events.APIGatewayProxyResponse{StatusCode: status}, err
+		}
+		// dialogflow ignores the body of failed webhook calls, an empty
+		// fulfillment text still falls back to the static responses
+		rule.Text = ""
+	}
+
+	res := &df.WebhookResponse{FulfillmentText: rule.Text}
+	if errorPayloadKey != "" {
+		res.Payload = &_structpb.Struct{Fields: map[string]*_structpb.Value{
+			errorPayloadKey: toValue(errorPayload(req, w, class)),
+		}}
+	}
+	if rule.Mode == ErrorSpeech && w.IsAssistant() {
+		res.FulfillmentMessages = []*df.Intent_Message{simpleResponse(&df.Intent_Message_SimpleResponse{
+			TextToSpeech: rule.Text,
+			DisplayText:  rule.Text,
+		})}
+	}
+	w.reportUsage(res)
+	return respond(req, res)
+}
@@ -0,0 +1,94 @@
+package lambdadialogflow
+
+import (
+	"strings"
+	"time"
+
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// expiresParam holds the wall-clock expiry of a context or state value
+const expiresParam = "lambdadialogflow-expires"
+
+// frameworkPrefix prefixes the contexts and session values managed by the framework
+const frameworkPrefix = "lambdadialogflow-"
+
+var stateTTL time.Duration
+
+// persistentState are framework state kept regardless of the state TTL, like
+// the turn counter of the conversation log and granted consent
+var persistentState = map[string]bool{
+	turnContext:    true,
+	consentContext: true,
+	interactionKey: true,
+}
+
+// SetStateTTL limits the framework managed contexts and session values, like
+// pending confirmations, forms and pages, to ttl of wall-clock time since they
+// were last written. Context lifespans count turns, so without a TTL a user
+// returning days later would still find the state of the old conversation.
+// Expired state is treated as absent and its contexts are cleared.
+func SetStateTTL(ttl time.Duration) {
+	stateTTL = ttl
+}
+
+// SetTTL makes the context expire after ttl of wall-clock time, independent
+// of its lifespan in turns. Expired contexts are treated as absent and
+// cleared with the next request.
+func (c *OutputContext) SetTTL(ttl time.Duration) *OutputContext {
+	return c.SetParam(expiresParam, time.Now().Add(ttl).Format(time.RFC3339))
+}
+
+// expired reports whether the parameters carry an expiry in the past
+func expired(params *_structpb.Struct) bool {
+	return expiredAt(params.GetFields()[expiresParam].GetStringValue())
+}
+
+// expiredValue reports whether session state carries an expiry in the past
+func expiredValue(data map[string]interface{}) bool {
+	s, _ := data[expiresParam].(string)
+	return expiredAt(s)
+}
+
+// expiredAt reports whether the RFC 3339 time lies in the past
+func expiredAt(s string) bool {
+	if s == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return err == nil && time.Now().After(t)
+}
+
+// expireState clears the expired contexts and session values of the request
+// before it is routed
+func (w *Agent) expireState() {
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		if expired(ctx.Parameters) {
+			w.OutputContext(ctx.Name).SetLifespan(0)
+		}
+	}
+	for key, value := range w.sessionData {
+		if data, ok := value.(map[string]interface{}); ok && expiredValue(data) {
+			w.DeleteSessionValue(key)
+		}
+	}
+}
+
+// stampState stamps the expiry of the state TTL into the framework contexts
+// of the response without an expiry of their own
+func (w *Agent) stampState() {
+	if stateTTL <= 0 {
+		return
+	}
+	expires := time.Now().Add(stateTTL).Format(time.RFC3339)
+	for _, ctx := range w.res.OutputContexts {
+		name := strings.ToLower(shortContextName(ctx.Name))
+		if ctx.LifespanCount <= 0 || !strings.HasPrefix(name, frameworkPrefix) || persistentState[name] {
+			continue
+		}
+		if ctx.GetParameters().GetFields()[expiresParam] != nil {
+			continue
+		}
+		(&OutputContext{ctx: ctx}).SetParam(expiresParam, expires)
+	}
+}
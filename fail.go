@@ -0,0 +1,37 @@
+package lambdadialogflow
+
+// ErrorHandler handles one dialogflow request and may fail
+type ErrorHandler func(*Agent) error
+
+// handlerErrorStatus is the http status of turns failed by their handler
+var handlerErrorStatus = 500
+
+// WithError adapts an ErrorHandler to be registered like any other handler.
+// A returned error fails the turn, see Fail.
+func WithError(handler ErrorHandler) WebhookHandler {
+	return func(w *Agent) {
+		if err := handler(w); err != nil {
+			w.Fail(err)
+		}
+	}
+}
+
+// Fail marks the turn as failed. The response of the handler is dropped and
+// the error is presented according to the rules of the error policy for
+// ErrorHandlerFailed, e.g. with a "Something went wrong" text.
+func (w *Agent) Fail(err error) {
+	w.handlerErr = err
+}
+
+// failNoHandler fails the turn like a request without a handler, e.g. for
+// requests a handler refuses to answer
+func (w *Agent) failNoHandler(err error) {
+	w.failure = ErrorNoHandler
+	w.Fail(err)
+}
+
+// SetHandlerErrorStatus sets the http status of turns failed by their
+// handler, which dialogflow answers with the static responses of the intent
+func SetHandlerErrorStatus(status int) {
+	handlerErrorStatus = status
+}
@@ -0,0 +1,262 @@
+// Package firestore keeps the session data of lambdadialogflow in Firestore,
+// next to the dialogflow agent in its GCP project. It talks to the Firestore
+// REST API, so no GCP SDK is needed.
+package firestore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the base url of the Firestore REST API
+const DefaultEndpoint = "https://firestore.googleapis.com/v1"
+
+// Scope is the OAuth2 scope needed for Firestore, e.g. for
+// lambdadialogflow.ServiceAccountTokens
+const Scope = "https://www.googleapis.com/auth/datastore"
+
+// TokenSource provides OAuth2 access tokens of the Scope
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Store is a lambdadialogflow.SessionStore keeping one document per session,
+// set with lambdadialogflow.SetSessionStore
+type Store struct {
+	HTTPClient *http.Client
+	Tokens     TokenSource
+	Endpoint   string
+	Project    string
+	// Database defaults to "(default)"
+	Database   string
+	Collection string
+}
+
+// NewStore creates a store keeping the sessions in the collection of the
+// default database of the project
+func NewStore(tokens TokenSource, project, collection string) *Store {
+	return &Store{
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		Tokens:     tokens,
+		Endpoint:   DefaultEndpoint,
+		Project:    project,
+		Collection: collection,
+	}
+}
+
+// document is a Firestore document of the REST API
+type document struct {
+	Fields map[string]value `json:"fields"`
+}
+
+// value is a typed Firestore value, exactly one field is set
+type value struct {
+	NullValue      *string     `json:"nullValue,omitempty"`
+	BooleanValue   *bool       `json:"booleanValue,omitempty"`
+	IntegerValue   *string     `json:"integerValue,omitempty"`
+	DoubleValue    *float64    `json:"doubleValue,omitempty"`
+	TimestampValue *string     `json:"timestampValue,omitempty"`
+	StringValue    *string     `json:"stringValue,omitempty"`
+	ArrayValue     *arrayValue `json:"arrayValue,omitempty"`
+	MapValue       *document   `json:"mapValue,omitempty"`
+}
+
+// arrayValue is a Firestore array
+type arrayValue struct {
+	Values []value `json:"values"`
+}
+
+// Load returns the session data, or nil for a new session
+func (s *Store) Load(session string) (map[string]interface{}, error) {
+	var doc document
+	found, err := s.do("GET", session, nil, &doc)
+	if err != nil || !found {
+		return nil, err
+	}
+	return decodeFields(doc.Fields), nil
+}
+
+// Save replaces the session data
+func (s *Store) Save(session string, data map[string]interface{}) error {
+	doc := document{Fields: make(map[string]value, len(data))}
+	for key, v := range data {
+		encoded, err := encodeValue(v)
+		if err != nil {
+			return fmt.Errorf("unable to encode session value %v: %v", key, err)
+		}
+		doc.Fields[key] = encoded
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = s.do("PATCH", session, body, nil)
+	return err
+}
+
+// documentURL returns the url of the document of a session. Session names
+// contain slashes, which are not allowed in document ids.
+func (s *Store) documentURL(session string) string {
+	endpoint, database := s.Endpoint, s.Database
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if database == "" {
+		database = "(default)"
+	}
+	id := strings.Replace(session, "/", "_", -1)
+	return fmt.Sprintf("%v/projects/%v/databases/%v/documents/%v/%v",
+		endpoint, s.Project, url.PathEscape(database), s.Collection, url.PathEscape(id))
+}
+
+// do sends a request for the document of the session, decoding the response
+// into out. It reports false if the document does not exist.
+func (s *Store) do(method, session string, body []byte, out interface{}) (bool, error) {
+	req, err := http.NewRequest(method, s.documentURL(session), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Tokens != nil {
+		token, err := s.Tokens.Token()
+		if err != nil {
+			return false, fmt.Errorf("unable to get access token: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return false, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("firestore returned %v: %s", res.Status, b)
+	}
+	if out != nil {
+		if err := json.Unmarshal(b, out); err != nil {
+			return false, fmt.Errorf("unable to decode document: %v", err)
+		}
+	}
+	return true, nil
+}
+
+// encodeValue converts a session value into a Firestore value
+func encodeValue(v interface{}) (value, error) {
+	switch t := v.(type) {
+	case nil:
+		null := "NULL_VALUE"
+		return value{NullValue: &null}, nil
+	case bool:
+		return value{BooleanValue: &t}, nil
+	case string:
+		return value{StringValue: &t}, nil
+	case float64:
+		return value{DoubleValue: &t}, nil
+	case float32:
+		f := float64(t)
+		return value{DoubleValue: &f}, nil
+	case int:
+		i := strconv.Itoa(t)
+		return value{IntegerValue: &i}, nil
+	case int64:
+		i := strconv.FormatInt(t, 10)
+		return value{IntegerValue: &i}, nil
+	case time.Time:
+		ts := t.UTC().Format(time.RFC3339Nano)
+		return value{TimestampValue: &ts}, nil
+	case []interface{}:
+		array := &arrayValue{Values: make([]value, 0, len(t))}
+		for _, item := range t {
+			encoded, err := encodeValue(item)
+			if err != nil {
+				return value{}, err
+			}
+			array.Values = append(array.Values, encoded)
+		}
+		return value{ArrayValue: array}, nil
+	case []string:
+		items := make([]interface{}, len(t))
+		for i, item := range t {
+			items[i] = item
+		}
+		return encodeValue(items)
+	case map[string]interface{}:
+		m := &document{Fields: make(map[string]value, len(t))}
+		for key, item := range t {
+			encoded, err := encodeValue(item)
+			if err != nil {
+				return value{}, err
+			}
+			m.Fields[key] = encoded
+		}
+		return value{MapValue: m}, nil
+	}
+	// other types like structs are stored as they encode to json
+	b, err := json.Marshal(v)
+	if err != nil {
+		return value{}, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return value{}, err
+	}
+	return encodeValue(generic)
+}
+
+// decodeFields converts the fields of a Firestore document into session data
+func decodeFields(fields map[string]value) map[string]interface{} {
+	data := make(map[string]interface{}, len(fields))
+	for key, v := range fields {
+		data[key] = decodeValue(v)
+	}
+	return data
+}
+
+// decodeValue converts a Firestore value into a session value. Integers
+// become int64 and timestamps time.Time.
+func decodeValue(v value) interface{} {
+	switch {
+	case v.BooleanValue != nil:
+		return *v.BooleanValue
+	case v.IntegerValue != nil:
+		i, _ := strconv.ParseInt(*v.IntegerValue, 10, 64)
+		return i
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.TimestampValue != nil:
+		t, err := time.Parse(time.RFC3339Nano, *v.TimestampValue)
+		if err != nil {
+			return *v.TimestampValue
+		}
+		return t
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.ArrayValue != nil:
+		items := make([]interface{}, 0, len(v.ArrayValue.Values))
+		for _, item := range v.ArrayValue.Values {
+			items = append(items, decodeValue(item))
+		}
+		return items
+	case v.MapValue != nil:
+		return decodeFields(v.MapValue.Fields)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package firestore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type staticToken string
+
+func (t staticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	docs := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if got := req.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch req.Method {
+		case "GET":
+			doc, ok := docs[req.URL.EscapedPath()]
+			if !ok {
+				http.NotFound(rw, req)
+				return
+			}
+			rw.Write(doc)
+		case "PATCH":
+			b, _ := ioutil.ReadAll(req.Body)
+			docs[req.URL.EscapedPath()] = b
+			rw.Write(b)
+		}
+	}))
+	defer server.Close()
+
+	s := NewStore(staticToken("secret"), "my-project", "sessions")
+	s.Endpoint = server.URL
+	session := "projects/my-project/agent/sessions/123"
+
+	data, err := s.Load(session)
+	if err != nil || data != nil {
+		t.Fatalf("Load of new session = %v, %v", data, err)
+	}
+
+	want := map[string]interface{}{
+		"name":   "Ada",
+		"count":  int64(3),
+		"score":  0.5,
+		"opt_in": true,
+		"none":   nil,
+		"tags":   []interface{}{"a", "b"},
+		"form":   map[string]interface{}{"step": int64(2)},
+	}
+	if err := s.Save(session, want); err != nil {
+		t.Fatal(err)
+	}
+	path := "/projects/my-project/databases/%28default%29/documents/sessions/projects_my-project_agent_sessions_123"
+	if _, ok := docs[path]; !ok {
+		t.Fatalf("document not written to %v, got %v", path, docs)
+	}
+	got, err := s.Load(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load = %#v, want %#v", got, want)
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"a", `{"stringValue":"a"}`},
+		{42, `{"integerValue":"42"}`},
+		{1.5, `{"doubleValue":1.5}`},
+		{false, `{"booleanValue":false}`},
+		{nil, `{"nullValue":"NULL_VALUE"}`},
+		{[]string{"x"}, `{"arrayValue":{"values":[{"stringValue":"x"}]}}`},
+		{struct {
+			A string `json:"a"`
+		}{"b"}, `{"mapValue":{"fields":{"a":{"stringValue":"b"}}}}`},
+	}
+	for _, test := range tests {
+		v, err := encodeValue(test.in)
+		if err != nil {
+			t.Errorf("encodeValue(%v): %v", test.in, err)
+			continue
+		}
+		b, _ := json.Marshal(v)
+		if string(b) != test.want {
+			t.Errorf("encodeValue(%v) = %s, want %s", test.in, b, test.want)
+		}
+	}
+}
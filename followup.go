@@ -0,0 +1,52 @@
+package lambdadialogflow
+
+import "strings"
+
+// Kinds of follow-up intents offered by the dialogflow console
+const (
+	FollowupYes    = "yes"
+	FollowupNo     = "no"
+	FollowupCustom = "custom"
+)
+
+// Followup registers handlers for the follow-up intents of a parent intent
+type Followup struct {
+	router *Router
+	parent string
+}
+
+// RegisterFollowup returns a Followup for the intent with the given display name.
+// Follow-up intents created in the dialogflow console get the action
+// "<Parent>.<Parent>-<kind>" and require the input context "<Parent>-followup",
+// where <Parent> is the display name without spaces.
+func RegisterFollowup(parentIntent string) *Followup {
+	return defaultRouter.RegisterFollowup(parentIntent)
+}
+
+// RegisterFollowup returns a Followup registering its handlers with the router
+func (r *Router) RegisterFollowup(parentIntent string) *Followup {
+	return &Followup{router: r, parent: strings.Replace(parentIntent, " ", "", -1)}
+}
+
+// Yes registers the handler for the "<parent> - yes" follow-up intent
+func (f *Followup) Yes(handler WebhookHandler) *Followup {
+	return f.Handle(FollowupYes, handler)
+}
+
+// No registers the handler for the "<parent> - no" follow-up intent
+func (f *Followup) No(handler WebhookHandler) *Followup {
+	return f.Handle(FollowupNo, handler)
+}
+
+// Handle registers the handler for the "<parent> - <kind>" follow-up intent
+func (f *Followup) Handle(kind string, handler WebhookHandler) *Followup {
+	action := f.parent + "." + f.parent + "-" + kind
+	f.router.Register(action, handler)
+	f.router.requiredContexts[action] = f.Context()
+	return f
+}
+
+// Context returns the name of the context that enables the follow-up intents
+func (f *Followup) Context() string {
+	return f.parent + "-followup"
+}
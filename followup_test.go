@@ -0,0 +1,37 @@
+package lambdadialogflow
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestFollowup(t *testing.T) {
+	r := NewRouter()
+	r.Register("order", func(w *Agent) {
+		w.Say("Anything else?")
+		w.SetContext("OrderPizza-followup", 2)
+	})
+	r.RegisterFollowup("Order Pizza").
+		Yes(func(w *Agent) { w.Say("What else?") }).
+		No(func(w *Agent) { w.Say("Bye.") })
+
+	c := newConversation(t, r)
+	turns := []struct{ action, want string }{
+		{"order", "Anything else?"},
+		{"OrderPizza.OrderPizza-no", "Bye."},
+	}
+	for _, turn := range turns {
+		if res := c.say(turn.action, "", nil); res.FulfillmentText != turn.want {
+			t.Errorf("%v: %q, want %q", turn.action, res.FulfillmentText, turn.want)
+		}
+	}
+
+	// without the follow-up context the branch is not reachable
+	resp, err := r.Serve(events.APIGatewayProxyRequest{
+		Body: `{"responseId":"x","session":"` + testSession + `","queryResult":{"action":"OrderPizza.OrderPizza-yes"}}`,
+	})
+	if err == nil || resp.StatusCode != 404 {
+		t.Errorf("follow-up without context: status %v, %v", resp.StatusCode, err)
+	}
+}
@@ -0,0 +1,228 @@
+package lambdadialogflow
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+const (
+	// formContext routes the turns of a form being filled to its action
+	formContext = "lambdadialogflow-form"
+	// formStatePrefix prefixes the state of a form being filled
+	formStatePrefix = "lambdadialogflow-form-"
+)
+
+// FieldType is the type of a form field
+type FieldType int
+
+// Field types of a form
+const (
+	FieldText FieldType = iota
+	FieldNumber
+	FieldDate
+)
+
+// FormField is one value collected by a form
+type FormField struct {
+	// Name of the dialogflow parameter filling the field
+	Name   string
+	Type   FieldType
+	Prompt string
+	// Validate normalizes the value, an error makes the form ask again
+	Validate func(string) (string, error)
+	// Invalid is said before the prompt after a failed validation
+	Invalid string
+	// Optional fields are only filled if the user happens to mention them
+	Optional bool
+}
+
+// Form collects a set of fields over multiple turns and hands the completed
+// values to Submit
+type Form struct {
+	Fields []FormField
+	// Confirm returns the question confirming the values before Submit.
+	// Without it the form is submitted right away.
+	Confirm func(FormValues) string
+	// Restart is said when the user rejects the values, before asking again
+	Restart string
+	Submit  func(*Agent, FormValues)
+}
+
+// FormValues are the validated values of a completed form, typed by their
+// fields: text fields are strings, number fields float64 and date fields
+// time.Time. Optional fields which were not filled are missing.
+type FormValues map[string]interface{}
+
+// String returns a text field
+func (v FormValues) String(name string) string {
+	s, _ := v[name].(string)
+	return s
+}
+
+// Number returns a number field
+func (v FormValues) Number(name string) float64 {
+	n, _ := v[name].(float64)
+	return n
+}
+
+// Date returns a date field
+func (v FormValues) Date(name string) time.Time {
+	t, _ := v[name].(time.Time)
+	return t
+}
+
+// Has reports whether a field was filled
+func (v FormValues) Has(name string) bool {
+	_, ok := v[name]
+	return ok
+}
+
+// RegisterForm registers the handler driving the form for action. Until the
+// form is complete the following turns are routed to it, unless the user
+// moves on to another intent with a registered handler.
+func RegisterForm(action string, form *Form) {
+	defaultRouter.RegisterForm(action, form)
+}
+
+// RegisterForm registers a form with the router, see RegisterForm
+func (r *Router) RegisterForm(action string, form *Form) {
+	r.Register(action, func(w *Agent) {
+		form.handle(w, action)
+	})
+}
+
+// handle drives the form for one turn
+func (f *Form) handle(w *Agent, action string) {
+	state := w.loadState(formStatePrefix + action)
+	values := map[string]string{}
+	if stored, ok := state["values"].(map[string]interface{}); ok {
+		for name, value := range stored {
+			values[name], _ = value.(string)
+		}
+	}
+	prompted, _ := state["field"].(string)
+
+	if confirming, _ := state["confirming"].(bool); confirming {
+		switch w.YesNo() {
+		case AnswerYes:
+			w.clearState(formStatePrefix + action)
+			w.ClearContexts(formContext)
+			f.Submit(w, f.typed(values))
+			return
+		case AnswerNo:
+			values = map[string]string{}
+			w.Say(f.Restart)
+		default:
+			f.ask(w, action, values, "", f.Confirm(f.typed(values)), true)
+			return
+		}
+	} else {
+		for _, field := range f.Fields {
+			raw, ok := f.value(w, field, field.Name == prompted)
+			if !ok {
+				continue
+			}
+			value, err := field.normalize(w, raw)
+			if err != nil {
+				f.ask(w, action, values, field.Name, strings.TrimSpace(field.Invalid+" "+field.Prompt), false)
+				return
+			}
+			values[field.Name] = value
+		}
+	}
+
+	for _, field := range f.Fields {
+		if _, ok := values[field.Name]; !ok && !field.Optional {
+			f.ask(w, action, values, field.Name, strings.TrimSpace(w.res.FulfillmentText+" "+field.Prompt), false)
+			return
+		}
+	}
+	if f.Confirm != nil {
+		f.ask(w, action, values, "", f.Confirm(f.typed(values)), true)
+		return
+	}
+	w.clearState(formStatePrefix + action)
+	w.ClearContexts(formContext)
+	f.Submit(w, f.typed(values))
+}
+
+// typed converts the normalized values into the types of their fields
+func (f *Form) typed(values map[string]string) FormValues {
+	typed := make(FormValues, len(values))
+	for _, field := range f.Fields {
+		value, ok := values[field.Name]
+		if !ok {
+			continue
+		}
+		switch field.Type {
+		case FieldNumber:
+			n, _ := strconv.ParseFloat(value, 64)
+			typed[field.Name] = n
+		case FieldDate:
+			t, _ := time.Parse(time.RFC3339, value)
+			typed[field.Name] = t
+		default:
+			typed[field.Name] = value
+		}
+	}
+	return typed
+}
+
+// value returns the raw value of a field mentioned in this turn. The query
+// text fills the field that was asked for if no parameter matched.
+func (f *Form) value(w *Agent, field FormField, prompted bool) (string, bool) {
+	v := w.getField(field.Name)
+	if s := v.GetStringValue(); s != "" {
+		return s, true
+	}
+	if n, ok := v.GetKind().(*_structpb.Value_NumberValue); ok {
+		return strconv.FormatFloat(n.NumberValue, 'f', -1, 64), true
+	}
+	if prompted && field.Type != FieldDate {
+		if text := strings.TrimSpace(w.req.GetQueryResult().GetQueryText()); text != "" {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// normalize converts the raw value according to the type of the field and
+// validates it
+func (field FormField) normalize(w *Agent, raw string) (string, error) {
+	switch field.Type {
+	case FieldNumber:
+		n, err := strconv.ParseFloat(strings.Replace(raw, ",", ".", 1), 64)
+		if err != nil {
+			return "", err
+		}
+		raw = strconv.FormatFloat(n, 'f', -1, 64)
+	case FieldDate:
+		t, err := w.parseTime(raw)
+		if err != nil {
+			return "", err
+		}
+		raw = t.Format(time.RFC3339)
+	}
+	if field.Validate != nil {
+		return field.Validate(raw)
+	}
+	return raw, nil
+}
+
+// ask says the text and stores the state of the form for the next turn
+func (f *Form) ask(w *Agent, action string, values map[string]string, field, text string, confirming bool) {
+	w.Say(text)
+	stored := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		stored[name] = value
+	}
+	w.saveState(formStatePrefix+action, map[string]interface{}{
+		"values":     stored,
+		"field":      field,
+		"confirming": confirming,
+	})
+	w.OutputContext(formContext).SetLifespan(DefaultLifespan).SetParam("action", action)
+}
@@ -0,0 +1,78 @@
+package lambdadialogflow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForm(t *testing.T) {
+	var submitted FormValues
+	r := NewRouter()
+	r.RegisterForm("table.book", &Form{
+		Fields: []FormField{
+			{Name: "name", Type: FieldText, Prompt: "Your name?"},
+			{Name: "guests", Type: FieldNumber, Prompt: "How many guests?", Invalid: "Sorry.",
+				Validate: func(s string) (string, error) {
+					if s == "0" {
+						return "", errors.New("no guests")
+					}
+					return s, nil
+				}},
+			{Name: "date", Type: FieldDate, Prompt: "Which day?"},
+			{Name: "note", Optional: true},
+		},
+		Confirm: func(v FormValues) string { return "Book for " + v.String("name") + "?" },
+		Restart: "Let's start over.",
+		Submit: func(w *Agent, v FormValues) {
+			submitted = v
+			w.Say("Booked.")
+		},
+	})
+	c := newConversation(t, r)
+
+	turns := []struct {
+		action, query string
+		params        map[string]interface{}
+		want          string
+	}{
+		{"table.book", "book a table", nil, "Your name?"},
+		{"", "Ada", nil, "How many guests?"},
+		{"", "0", nil, "Sorry. How many guests?"},
+		{"", "4,5", nil, "Which day?"},
+		{"", "tomorrow", map[string]interface{}{"date": "2020-05-01T12:00:00Z"}, "Book for Ada?"},
+		{"", "no", nil, "Let's start over. Your name?"},
+		{"", "Grace", map[string]interface{}{"guests": 2.0, "date": "2020-05-02T12:00:00Z"}, "Book for Grace?"},
+		{"", "yes", nil, "Booked."},
+	}
+	for i, turn := range turns {
+		res := c.say(turn.action, turn.query, turn.params)
+		if res.FulfillmentText != turn.want {
+			t.Fatalf("turn %v: %q, want %q", i+1, res.FulfillmentText, turn.want)
+		}
+	}
+
+	if submitted.String("name") != "Grace" || submitted.Number("guests") != 2 || !submitted.Has("date") || submitted.Has("note") {
+		t.Errorf("submitted %v", submitted)
+	}
+	if want := time.Date(2020, 5, 2, 12, 0, 0, 0, time.UTC); !submitted.Date("date").Equal(want) {
+		t.Errorf("date = %v, want %v", submitted.Date("date"), want)
+	}
+	for name := range c.contexts {
+		if strings.Contains(name, formContext) {
+			t.Errorf("context %v still active after submit", name)
+		}
+	}
+}
+
+func TestFormStateName(t *testing.T) {
+	r := NewRouter()
+	r.RegisterForm("order.create", &Form{Fields: []FormField{{Name: "item", Prompt: "What?"}}, Submit: func(*Agent, FormValues) {}})
+	res := newConversation(t, r).say("order.create", "order", nil)
+	for _, ctx := range res.OutputContexts {
+		if name := shortContextName(ctx.Name); invalidContextChars.MatchString(name) {
+			t.Errorf("invalid context id %q", name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package lambdadialogflow
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Match is a candidate value with its similarity to a query, 1 being an exact match
+type Match struct {
+	Value string
+	Score float64
+}
+
+// FuzzyMatch ranks the candidates by their similarity to the query, best match
+// first. The score is the better of the edit distance similarity of the whole
+// strings and the similarity of the candidate's words to the words of the query,
+// so "the blue one please" still matches the candidate "blue".
+func FuzzyMatch(query string, candidates []string) []Match {
+	q := normalizeText(query)
+	queryTokens := strings.Fields(q)
+
+	matches := make([]Match, 0, len(candidates))
+	for _, candidate := range candidates {
+		c := normalizeText(candidate)
+		score := similarity(q, c)
+		if tokenScore := tokenSimilarity(queryTokens, strings.Fields(c)); tokenScore > score {
+			score = tokenScore
+		}
+		matches = append(matches, Match{Value: candidate, Score: score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// MatchQuery fuzzy matches the query text of the request against the candidates
+// and returns all matches scoring at least minScore
+func (w *Agent) MatchQuery(candidates []string, minScore float64) []Match {
+	matches := FuzzyMatch(w.req.GetQueryResult().GetQueryText(), candidates)
+	for i, m := range matches {
+		if m.Score < minScore {
+			return matches[:i]
+		}
+	}
+	return matches
+}
+
+// normalizeText lowercases the text and replaces punctuation with spaces
+func normalizeText(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// tokenSimilarity averages the best similarity of each candidate token to any query token
+func tokenSimilarity(query, candidate []string) float64 {
+	if len(query) == 0 || len(candidate) == 0 {
+		return 0
+	}
+	var total float64
+	for _, c := range candidate {
+		var best float64
+		for _, q := range query {
+			if s := similarity(q, c); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+	return total / float64(len(candidate))
+}
+
+// similarity turns the levenshtein distance into a score between 0 and 1
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the number of single rune edits needed to turn a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -1,7 +1,16 @@
 module github.com/holgerarendt/lambda-dialogflow
 
+go 1.19
+
 require (
 	github.com/aws/aws-lambda-go v1.7.0
-	github.com/golang/protobuf v1.2.0
-	google.golang.org/genproto v0.0.0-20181127195345-31ac5d88444a
+	github.com/golang/protobuf v1.3.2
+	google.golang.org/genproto v0.0.0-20191230161307-f3c370f40bfb
+)
+
+require (
+	golang.org/x/net v0.0.0-20190213061140-3a22650c66bd // indirect
+	golang.org/x/sys v0.0.0-20180830151530-49385e6e1522 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	google.golang.org/grpc v1.19.0 // indirect
 )
@@ -0,0 +1,228 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HeatmapFlushAction is the scheduled action flushing the intent usage heatmap
+const HeatmapFlushAction = "lambdadialogflow-heatmap-flush"
+
+// HeatmapCell is the number of requests matching an intent within an hour
+type HeatmapCell struct {
+	Intent string    `json:"intent"`
+	Hour   time.Time `json:"hour"`
+	Count  int       `json:"count"`
+}
+
+// HeatmapSink receives the aggregated intent usage
+type HeatmapSink interface {
+	WriteHeatmap(cells []HeatmapCell) error
+}
+
+// heatmapKey identifies a cell of the heatmap
+type heatmapKey struct {
+	intent string
+	hour   time.Time
+}
+
+var (
+	heatmapMu     sync.Mutex
+	heatmapSink   HeatmapSink
+	heatmapCounts = make(map[heatmapKey]int)
+	heatmapHour   time.Time
+
+	// heatmapFlushMu serializes writes to the sink, it is never held by the
+	// request path
+	heatmapFlushMu  sync.Mutex
+	heatmapOnce     sync.Once
+	heatmapFlushes  = make(chan struct{}, 1)
+	heatmapBackoff  = time.Second
+	heatmapMaxDelay = time.Minute
+)
+
+// EnableHeatmap aggregates the requests per intent and hour and writes them
+// to the sink, giving conversation designers data on which intents are
+// rarely used. Completed hours are written in the background once the next
+// hour started, failed writes are retried with backoff. The rest is written
+// on Shutdown or with FlushHeatmap.
+func EnableHeatmap(sink HeatmapSink) {
+	heatmapMu.Lock()
+	heatmapSink = sink
+	heatmapMu.Unlock()
+	heatmapOnce.Do(func() {
+		OnShutdown(func() {
+			if err := FlushHeatmap(); err != nil {
+				log.Printf("unable to flush heatmap: %v", err)
+			}
+		})
+		RegisterScheduled(HeatmapFlushAction, func(ScheduledAction) error {
+			return FlushHeatmap()
+		})
+		go flushCompletedHours()
+	})
+}
+
+// recordHeatmap counts a request for the heatmap and triggers the background
+// flush when a new hour started
+func recordHeatmap(intent string, now time.Time) {
+	heatmapMu.Lock()
+	defer heatmapMu.Unlock()
+	if heatmapSink == nil {
+		return
+	}
+	hour := now.UTC().Truncate(time.Hour)
+	heatmapCounts[heatmapKey{intent: intent, hour: hour}]++
+	if hour.After(heatmapHour) {
+		if !heatmapHour.IsZero() {
+			select {
+			case heatmapFlushes <- struct{}{}:
+			default:
+			}
+		}
+		heatmapHour = hour
+	}
+}
+
+// flushCompletedHours writes the completed hours whenever triggered by
+// recordHeatmap, retrying failed writes with exponential backoff
+func flushCompletedHours() {
+	for range heatmapFlushes {
+		delay := heatmapBackoff
+		for {
+			err := flushHeatmap(time.Now().UTC().Truncate(time.Hour))
+			if err == nil {
+				break
+			}
+			log.Printf("unable to flush heatmap, retrying in %v: %v", delay, err)
+			time.Sleep(delay)
+			if delay *= 2; delay > heatmapMaxDelay {
+				delay = heatmapMaxDelay
+			}
+		}
+	}
+}
+
+// FlushHeatmap writes all counts aggregated so far
+func FlushHeatmap() error {
+	return flushHeatmap(time.Now().UTC().Add(time.Hour))
+}
+
+// flushHeatmap writes the cells of the hours before the given one. The counts
+// are copied under the lock and only removed once the sink wrote them, so
+// requests are never blocked by the sink.
+func flushHeatmap(before time.Time) error {
+	heatmapFlushMu.Lock()
+	defer heatmapFlushMu.Unlock()
+
+	heatmapMu.Lock()
+	sink := heatmapSink
+	var cells []HeatmapCell
+	for key, count := range heatmapCounts {
+		if key.hour.Before(before) {
+			cells = append(cells, HeatmapCell{Intent: key.intent, Hour: key.hour, Count: count})
+		}
+	}
+	heatmapMu.Unlock()
+	if sink == nil || len(cells) == 0 {
+		return nil
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if !cells[i].Hour.Equal(cells[j].Hour) {
+			return cells[i].Hour.Before(cells[j].Hour)
+		}
+		return cells[i].Intent < cells[j].Intent
+	})
+	if err := sink.WriteHeatmap(cells); err != nil {
+		return err
+	}
+
+	heatmapMu.Lock()
+	defer heatmapMu.Unlock()
+	for _, cell := range cells {
+		key := heatmapKey{intent: cell.Intent, hour: cell.Hour}
+		// requests may have been counted for the current hour meanwhile
+		if heatmapCounts[key] -= cell.Count; heatmapCounts[key] <= 0 {
+			delete(heatmapCounts, key)
+		}
+	}
+	return nil
+}
+
+// metricsHeatmapSink writes the heatmap as CloudWatch embedded metric format
+type metricsHeatmapSink struct {
+	namespace string
+}
+
+// NewMetricsHeatmapSink creates a sink emitting an IntentUsage metric per
+// cell, dimensioned by intent and timestamped with the hour, to stdout for
+// CloudWatch to extract like the request metrics
+func NewMetricsHeatmapSink(namespace string) HeatmapSink {
+	return metricsHeatmapSink{namespace: namespace}
+}
+
+// WriteHeatmap emits one record per cell
+func (s metricsHeatmapSink) WriteHeatmap(cells []HeatmapCell) error {
+	for _, cell := range cells {
+		intent := cell.Intent
+		if intent == "" {
+			intent = "none"
+		}
+		b, err := json.Marshal(map[string]interface{}{
+			"_aws": map[string]interface{}{
+				"Timestamp": cell.Hour.UnixNano() / int64(time.Millisecond),
+				"CloudWatchMetrics": []interface{}{map[string]interface{}{
+					"Namespace":  s.namespace,
+					"Dimensions": [][]string{{"Intent", "Environment"}},
+					"Metrics":    []metric{{Name: "IntentUsage", Unit: "Count"}},
+				}},
+			},
+			"Intent":      intent,
+			"Environment": metricsEnvironment,
+			"IntentUsage": cell.Count,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := metricsWriter.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadHeatmapSink writes the heatmap as CSV objects
+type uploadHeatmapSink struct {
+	uploader Uploader
+	prefix   string
+}
+
+// NewUploadHeatmapSink creates a sink writing each flush as CSV object with
+// the columns hour, intent and count, e.g. to S3 for analysis with Athena.
+// Objects are keyed by the prefix and the time of the flush.
+func NewUploadHeatmapSink(uploader Uploader, prefix string) HeatmapSink {
+	return uploadHeatmapSink{uploader: uploader, prefix: prefix}
+}
+
+// WriteHeatmap uploads the cells as one CSV object
+func (s uploadHeatmapSink) WriteHeatmap(cells []HeatmapCell) error {
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	out.Write([]string{"hour", "intent", "count"})
+	for _, cell := range cells {
+		out.Write([]string{cell.Hour.Format(time.RFC3339), cell.Intent, strconv.Itoa(cell.Count)})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return err
+	}
+	key := s.prefix + time.Now().UTC().Format("2006-01-02T15-04-05.000000000") + ".csv"
+	_, err := s.uploader.Upload(key, "text/csv", &buf)
+	return err
+}
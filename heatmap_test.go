@@ -0,0 +1,85 @@
+package lambdadialogflow
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type blockingHeatmapSink struct {
+	mu      sync.Mutex
+	release chan struct{}
+	fail    bool
+	written [][]HeatmapCell
+}
+
+func (s *blockingHeatmapSink) WriteHeatmap(cells []HeatmapCell) error {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		s.fail = false
+		return errors.New("unavailable")
+	}
+	s.written = append(s.written, cells)
+	return nil
+}
+
+func TestHeatmapFlushesInBackground(t *testing.T) {
+	heatmapBackoff = time.Millisecond
+	sink := &blockingHeatmapSink{release: make(chan struct{}), fail: true}
+	EnableHeatmap(sink)
+	defer func() {
+		heatmapMu.Lock()
+		heatmapSink = nil
+		heatmapCounts = make(map[heatmapKey]int)
+		heatmapHour = time.Time{}
+		heatmapMu.Unlock()
+	}()
+
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour)
+	recordHeatmap("welcome", hour)
+	recordHeatmap("welcome", hour.Add(time.Minute))
+
+	// the sink blocks, requests of the next hour must not
+	done := make(chan struct{})
+	go func() {
+		recordHeatmap("order", hour.Add(time.Hour))
+		recordHeatmap("order", hour.Add(time.Hour+time.Minute))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("recordHeatmap blocked on the sink")
+	}
+
+	// the first write fails and is retried
+	sink.release <- struct{}{}
+	sink.release <- struct{}{}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		sink.mu.Lock()
+		n := len(sink.written)
+		sink.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("completed hour was not written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := sink.written[0]; len(got) != 1 || got[0].Intent != "welcome" || got[0].Count != 2 {
+		t.Errorf("written = %+v, want 2 welcome", got)
+	}
+
+	heatmapMu.Lock()
+	remaining := heatmapCounts[heatmapKey{intent: "order", hour: hour.Add(time.Hour)}]
+	heatmapMu.Unlock()
+	if remaining != 2 {
+		t.Errorf("current hour count = %d, want 2", remaining)
+	}
+}
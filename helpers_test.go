@@ -0,0 +1,105 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang/protobuf/jsonpb"
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+const testSession = "projects/p/agent/sessions/s"
+
+// testRequest returns an agent for the webhook request given as JSON
+func testRequest(t *testing.T, body string) *Agent {
+	t.Helper()
+	w, err := Parse([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// testAgent returns an agent for a request with the query result given as JSON
+func testAgent(t *testing.T, queryResult string) *Agent {
+	t.Helper()
+	return testRequest(t, `{"responseId":"1","session":"`+testSession+`","queryResult":`+queryResult+`}`)
+}
+
+// conversation sends turns to a router, carrying the contexts between turns
+// like dialogflow does
+type conversation struct {
+	t        *testing.T
+	router   *Router
+	turn     int
+	contexts map[string]*df.Context
+}
+
+func newConversation(t *testing.T, r *Router) *conversation {
+	return &conversation{t: t, router: r, contexts: make(map[string]*df.Context)}
+}
+
+// say sends a turn and returns the response
+func (c *conversation) say(action, query string, params map[string]interface{}) *df.WebhookResponse {
+	c.t.Helper()
+	c.turn++
+	req := &df.WebhookRequest{
+		ResponseId: strconv.Itoa(c.turn),
+		Session:    testSession,
+		QueryResult: &df.QueryResult{
+			Action:       action,
+			QueryText:    query,
+			LanguageCode: "en",
+			Parameters:   c.parameters(params),
+		},
+	}
+	for _, ctx := range c.contexts {
+		req.QueryResult.OutputContexts = append(req.QueryResult.OutputContexts, ctx)
+	}
+	body, err := (&jsonpb.Marshaler{}).MarshalToString(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	resp, err := c.router.Serve(events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: body})
+	if err != nil {
+		c.t.Fatalf("turn %v: %v", c.turn, err)
+	}
+	res := &df.WebhookResponse{}
+	if err := (&jsonpb.Unmarshaler{AllowUnknownFields: true}).Unmarshal(strings.NewReader(resp.Body), res); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for name, ctx := range c.contexts {
+		if ctx.LifespanCount--; ctx.LifespanCount <= 0 {
+			delete(c.contexts, name)
+		}
+	}
+	for _, ctx := range res.OutputContexts {
+		if ctx.LifespanCount <= 0 {
+			delete(c.contexts, ctx.Name)
+			continue
+		}
+		c.contexts[ctx.Name] = ctx
+	}
+	return res
+}
+
+// parameters converts the parameters of a turn like they are sent as JSON
+func (c *conversation) parameters(params map[string]interface{}) *_structpb.Struct {
+	c.t.Helper()
+	b, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	parameters := &_structpb.Struct{}
+	if params != nil {
+		if err := jsonpb.UnmarshalString(string(b), parameters); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	return parameters
+}
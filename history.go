@@ -0,0 +1,107 @@
+package lambdadialogflow
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// turnContext counts the turns of a conversation for the conversation log
+const turnContext = "lambdadialogflow-turn"
+
+// Turn is one request and response of a conversation
+type Turn struct {
+	Session    string                 `json:"session"`
+	Turn       int                    `json:"turn"`
+	Time       time.Time              `json:"time"`
+	Action     string                 `json:"action"`
+	Intent     string                 `json:"intent"`
+	QueryText  string                 `json:"queryText"`
+	Confidence float32                `json:"confidence,omitempty"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Response   string                 `json:"response"`
+}
+
+// ConversationLog stores the turns of conversations. A DynamoDB implementation
+// uses a single table with the session as partition key and the turn number
+// as sort key, History then is a query in descending sort key order.
+type ConversationLog interface {
+	Append(turn Turn) error
+	History(session string, limit int) ([]Turn, error)
+}
+
+var conversationLog ConversationLog
+
+// MemoryLog is a ConversationLog keeping the turns in the memory of the lambda
+// instance. It loses the turns on cold starts, so it is meant for tests and
+// local development.
+type MemoryLog struct {
+	mutex sync.Mutex
+	turns map[string][]Turn
+}
+
+// NewMemoryLog creates an empty in-memory conversation log
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{turns: make(map[string][]Turn)}
+}
+
+// Append adds the turn to its session
+func (m *MemoryLog) Append(turn Turn) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.turns[turn.Session] = append(m.turns[turn.Session], turn)
+	return nil
+}
+
+// History returns up to limit turns of the session, latest first
+func (m *MemoryLog) History(session string, limit int) ([]Turn, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	turns := m.turns[session]
+	if limit > len(turns) {
+		limit = len(turns)
+	}
+	history := make([]Turn, 0, limit)
+	for i := len(turns) - 1; i >= len(turns)-limit; i-- {
+		history = append(history, turns[i])
+	}
+	return history, nil
+}
+
+// SetConversationLog enables logging every turn to the given log
+func SetConversationLog(l ConversationLog) {
+	conversationLog = l
+}
+
+// History returns up to limit previous turns of the current session, latest first
+func (w *Agent) History(limit int) ([]Turn, error) {
+	if conversationLog == nil {
+		return nil, errors.New("no conversation log configured")
+	}
+	return conversationLog.History(w.Session(), limit)
+}
+
+// logTurn appends the current turn to the conversation log, if enabled
+func (w *Agent) logTurn() error {
+	if conversationLog == nil {
+		return nil
+	}
+	turn := int(w.inputContext(turnContext).GetParameters().GetFields()["turn"].GetNumberValue()) + 1
+	w.OutputContext(turnContext).SetLifespan(DefaultLifespan).SetParam("turn", turn)
+
+	entry := Turn{
+		Session:    w.Session(),
+		Turn:       turn,
+		Time:       time.Now(),
+		Action:     w.Action(),
+		Intent:     w.req.GetQueryResult().GetIntent().GetDisplayName(),
+		QueryText:  w.req.GetQueryResult().GetQueryText(),
+		Confidence: w.req.GetQueryResult().GetIntentDetectionConfidence(),
+		Params:     fromStruct(w.req.GetQueryResult().GetParameters()),
+		Response:   w.res.FulfillmentText,
+	}
+	if w.capture("conversationLog", entry) {
+		return nil
+	}
+	return conversationLog.Append(entry)
+}
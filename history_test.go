@@ -0,0 +1,49 @@
+package lambdadialogflow
+
+import "testing"
+
+func TestMemoryLog(t *testing.T) {
+	log := NewMemoryLog()
+	SetConversationLog(log)
+	defer SetConversationLog(nil)
+
+	var previous []Turn
+	r := NewRouter()
+	r.Register("echo", func(w *Agent) {
+		history, err := w.History(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		previous = history
+		w.Say("you said " + w.Query())
+	})
+	c := newConversation(t, r)
+	c.say("echo", "one", nil)
+	if len(previous) != 0 {
+		t.Errorf("history of first turn = %v", previous)
+	}
+	c.say("echo", "two", nil)
+	if len(previous) != 1 || previous[0].QueryText != "one" || previous[0].Response != "you said one" {
+		t.Errorf("history of second turn = %v", previous)
+	}
+	c.say("echo", "three", map[string]interface{}{"color": "red"})
+
+	history, err := log.History(testSession, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("history = %v", history)
+	}
+	for i, want := range []string{"three", "two", "one"} {
+		if history[i].QueryText != want || history[i].Turn != 3-i {
+			t.Errorf("turn %v = %+v, want %v", i, history[i], want)
+		}
+	}
+	if history[0].Params["color"] != "red" {
+		t.Errorf("params = %v", history[0].Params)
+	}
+	if other, _ := log.History("projects/p/agent/sessions/other", 5); len(other) != 0 {
+		t.Errorf("history of other session = %v", other)
+	}
+}
@@ -0,0 +1,143 @@
+package lambdadialogflow
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var (
+	shutdownTimeout = 10 * time.Second
+	shutdownMu      sync.Mutex
+	shutdownHooks   []func()
+)
+
+// SetShutdownTimeout sets how long ListenAndServe waits for in-flight requests on shutdown
+func SetShutdownTimeout(timeout time.Duration) {
+	shutdownTimeout = timeout
+}
+
+// OnShutdown registers a function which is called after the last in-flight
+// request finished, e.g. to flush analytics or transcript buffers, see Shutdown
+func OnShutdown(hook func()) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, hook)
+}
+
+// runShutdownHooks calls the registered shutdown hooks in registration order
+func runShutdownHooks() {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	for _, hook := range shutdownHooks {
+		hook()
+	}
+}
+
+// Shutdown runs the OnShutdown hooks. On lambda the application calls it
+// before the execution environment goes away, e.g. from its own SIGTERM
+// handler, which lambda only sends to functions with a registered extension.
+// ListenAndServe calls it after draining the in-flight requests.
+func Shutdown() {
+	runShutdownHooks()
+}
+
+// HTTPHandler serves dialogflow webhook requests over plain net/http, e.g. for
+// local development or when running in a container instead of lambda
+func HTTPHandler() http.Handler {
+	return defaultRouter.HTTPHandler()
+}
+
+// HTTPHandler serves the webhook requests of the router over plain net/http
+func (router *Router) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		headers := make(map[string]string, len(r.Header))
+		for name := range r.Header {
+			headers[name] = r.Header.Get(name)
+		}
+
+		resp, err := router.ServeContext(r.Context(), events.APIGatewayProxyRequest{
+			HTTPMethod: r.Method,
+			Path:       r.URL.Path,
+			Headers:    headers,
+			Body:       string(body),
+		})
+		if err != nil {
+			log.Printf("webhook request failed: %v", err)
+			if resp.StatusCode == 0 {
+				resp.StatusCode = http.StatusInternalServerError
+			}
+		}
+		writeHTTPResponse(rw, resp)
+	})
+}
+
+// writeHTTPResponse writes the api gateway response to a http response
+func writeHTTPResponse(rw http.ResponseWriter, resp events.APIGatewayProxyResponse) {
+	for name, value := range resp.Headers {
+		rw.Header().Set(name, value)
+	}
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body = decoded
+	}
+	rw.WriteHeader(resp.StatusCode)
+	rw.Write(body)
+}
+
+// ListenAndServe serves webhook requests on addr until the process receives
+// SIGINT or SIGTERM. In-flight requests are drained for up to the shutdown timeout,
+// then the OnShutdown hooks run, matching what the lambda runtime does implicitly.
+func ListenAndServe(addr string) error {
+	return defaultRouter.ListenAndServe(addr)
+}
+
+// ListenAndServe serves the webhook requests of the router on addr, see ListenAndServe
+func (router *Router) ListenAndServe(addr string) error {
+	server := &http.Server{Addr: addr, Handler: router.HTTPHandler()}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	// stop ends the signal goroutine if the server fails to listen
+	stop := make(chan struct{})
+	defer close(stop)
+	done := make(chan error, 1)
+	go func() {
+		select {
+		case <-signals:
+		case <-stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		done <- server.Shutdown(ctx)
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	err := <-done
+	runShutdownHooks()
+	return err
+}
@@ -0,0 +1,77 @@
+package lambdadialogflow
+
+import (
+	"strconv"
+
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// payloadValue walks the original request payload along the given keys
+func (w *Agent) payloadValue(keys ...string) *_structpb.Value {
+	s := w.req.GetOriginalDetectIntentRequest().GetPayload()
+	var v *_structpb.Value
+	for _, key := range keys {
+		if s == nil {
+			return nil
+		}
+		v = s.GetFields()[key]
+		s = v.GetStructValue()
+	}
+	return v
+}
+
+// payloadString returns a string or number from the original request payload as string
+func (w *Agent) payloadString(keys ...string) string {
+	v := w.payloadValue(keys...)
+	if n, ok := v.GetKind().(*_structpb.Value_NumberValue); ok {
+		return strconv.FormatFloat(n.NumberValue, 'f', -1, 64)
+	}
+	return v.GetStringValue()
+}
+
+// FacebookSenderID returns the page scoped id of the messenger user
+func (w *Agent) FacebookSenderID() string {
+	return w.payloadString("data", "sender", "id")
+}
+
+// SlackUserID returns the id of the slack user who sent the message
+func (w *Agent) SlackUserID() string {
+	if id := w.payloadString("data", "event", "user"); id != "" {
+		return id
+	}
+	return w.payloadString("data", "user")
+}
+
+// TelegramChatID returns the id of the telegram chat the message was sent in
+func (w *Agent) TelegramChatID() string {
+	if id := w.payloadString("data", "message", "chat", "id"); id != "" {
+		return id
+	}
+	return w.payloadString("data", "callback_query", "message", "chat", "id")
+}
+
+// GoogleUserID returns the user id sent by actions on google, if available
+func (w *Agent) GoogleUserID() string {
+	return w.payloadString("user", "userId")
+}
+
+// GoogleDisplayName returns the name from the google user profile, which is
+// only present after the user granted the NAME permission
+func (w *Agent) GoogleDisplayName() string {
+	return w.payloadString("user", "profile", "displayName")
+}
+
+// UserID returns the stable user identifier of the platform the request came from
+func (w *Agent) UserID() string {
+	switch w.Source() {
+	case SourceFacebookMessenger:
+		return w.FacebookSenderID()
+	case SourceSlack:
+		return w.SlackUserID()
+	case SourceTelegram:
+		return w.TelegramChatID()
+	case SourceGoogleAssistant:
+		return w.GoogleUserID()
+	}
+	return ""
+}
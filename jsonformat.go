@@ -0,0 +1,25 @@
+package lambdadialogflow
+
+import "github.com/golang/protobuf/jsonpb"
+
+// JSONFormat controls how responses are marshaled
+type JSONFormat struct {
+	// OrigName uses the snake_case field names of the proto definition like
+	// fulfillment_text instead of the lowerCamelCase json names
+	OrigName bool
+	// EmitDefaults includes fields with zero values
+	EmitDefaults bool
+}
+
+var jsonFormat JSONFormat
+
+// SetJSONFormat sets the format of the response body, e.g. for legacy
+// clients expecting snake_case keys. Dialogflow accepts both casings.
+func SetJSONFormat(format JSONFormat) {
+	jsonFormat = format
+}
+
+// responseMarshaler returns the marshaler for response bodies
+func responseMarshaler() *jsonpb.Marshaler {
+	return &jsonpb.Marshaler{OrigName: jsonFormat.OrigName, EmitDefaults: jsonFormat.EmitDefaults}
+}
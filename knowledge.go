@@ -0,0 +1,375 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KnowledgeAnswer is an answer of a knowledge connector
+type KnowledgeAnswer struct {
+	// Source is the resource name of the knowledge base document
+	Source      string `json:"source"`
+	FAQQuestion string `json:"faqQuestion"`
+	Answer      string `json:"answer"`
+	// MatchConfidenceLevel is LOW, MEDIUM or HIGH
+	MatchConfidenceLevel string  `json:"matchConfidenceLevel"`
+	MatchConfidence      float32 `json:"matchConfidence"`
+}
+
+// decodeKnowledgeAnswers extracts the knowledge answers of the query result
+// and the alternative query results from the webhook request
+func decodeKnowledgeAnswers(beta betaRequest) ([]KnowledgeAnswer, error) {
+	answers := beta.QueryResult.KnowledgeAnswers.Answers
+	for _, raw := range beta.AlternativeQueryResults {
+		var result betaQueryResult
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("unable to decode alternative query result: %v", err)
+		}
+		answers = append(answers, result.KnowledgeAnswers.Answers...)
+	}
+	return answers, nil
+}
+
+// KnowledgeAnswers returns the answers of knowledge connectors dialogflow
+// sends with v2beta1 requests, from the query result and the alternative
+// query results. Dialogflow v2 requests never contain knowledge answers.
+func (w *Agent) KnowledgeAnswers() []KnowledgeAnswer {
+	return w.knowledge
+}
+
+// Passage is a text of the knowledge base answering a question
+type Passage struct {
+	Title   string
+	Snippet string
+	URL     string
+	// Score is the relevance of the answer on the scale of the answerer
+	Score float64
+}
+
+// Answerer searches a knowledge base like an FAQ corpus for answers to a
+// question, best answer first
+type Answerer interface {
+	Answer(ctx context.Context, query, languageCode string) ([]Passage, error)
+}
+
+// agentAnswerer is implemented by answerers calling remote services, For
+// returns the answerer to use within the handler of the agent
+type agentAnswerer interface {
+	For(w *Agent) Answerer
+}
+
+// KnowledgeFallback answers long-tail questions from a knowledge base. Queries
+// without an answer scoring at least MinScore are passed on to Next, e.g. the
+// handler of an LLMFallback, forming a chain of fallbacks.
+type KnowledgeFallback struct {
+	Answerer Answerer
+	MinScore float64
+	// MaxSnippet is the length of the spoken snippet in runes, defaults to 300
+	MaxSnippet int
+	// MaxSources is the number of linked sources, defaults to 3
+	MaxSources int
+	Next       WebhookHandler
+}
+
+// Handler returns a handler answering with the best answer of the knowledge
+// base, e.g. to be set with NotFound
+func (f *KnowledgeFallback) Handler() WebhookHandler {
+	return func(w *Agent) {
+		answerer := f.Answerer
+		if a, ok := answerer.(agentAnswerer); ok {
+			answerer = a.For(w)
+		}
+		answers, err := answerer.Answer(w.Context(), w.req.GetQueryResult().GetQueryText(), w.req.GetQueryResult().GetLanguageCode())
+		if err != nil {
+			log.Printf("unable to search knowledge base: %v", err)
+		}
+		for i, answer := range answers {
+			if answer.Score < f.MinScore {
+				answers = answers[:i]
+				break
+			}
+		}
+		if len(answers) == 0 {
+			if f.Next != nil {
+				f.Next(w)
+			}
+			return
+		}
+		f.respond(w, answers)
+	}
+}
+
+// respond says the snippet of the best answer and links the sources
+func (f *KnowledgeFallback) respond(w *Agent, answers []Passage) {
+	maxSnippet, maxSources := f.MaxSnippet, f.MaxSources
+	if maxSnippet <= 0 {
+		maxSnippet = 300
+	}
+	if maxSources <= 0 {
+		maxSources = 3
+	}
+	w.Say(FormatSnippet(answers[0].Snippet, maxSnippet))
+
+	var buttons []CardButton
+	for _, answer := range answers {
+		if answer.URL == "" || len(buttons) == maxSources {
+			continue
+		}
+		buttons = append(buttons, CardButton{Text: answer.Title, Postback: answer.URL})
+	}
+	if len(buttons) == 0 {
+		return
+	}
+	w.AddCard(answers[0].Title, "", "", buttons...)
+	w.AddLinkOutSuggestion(buttons[0].Text, buttons[0].Postback)
+}
+
+// highlightTags matches the tags search engines wrap around matched terms
+var highlightTags = regexp.MustCompile(`</?(em|mark|b|strong)>`)
+
+// FormatSnippet turns a search snippet into a response text: highlight tags are
+// removed, whitespace is collapsed and long snippets are cut after the last
+// complete sentence within length runes
+func FormatSnippet(snippet string, length int) string {
+	text := strings.Join(strings.Fields(highlightTags.ReplaceAllString(snippet, "")), " ")
+	if len([]rune(text)) <= length {
+		return text
+	}
+	head := string([]rune(text)[:length])
+	if cut := strings.LastIndexAny(head, ".!?"); cut > len(head)/2 {
+		return head[:cut+1]
+	}
+	return truncateText(text, length)
+}
+
+// Document is an entry of the corpus searched by a KeywordAnswerer
+type Document struct {
+	Title string
+	Body  string
+	URL   string
+	// Language of the document, documents without one match every language
+	Language string
+	Keywords []string
+}
+
+// KeywordAnswerer searches an in-memory corpus by the words of the query. The
+// score is the share of query words found in a document, snippets are the
+// sentences of the body with the most query words.
+type KeywordAnswerer struct {
+	Documents []Document
+}
+
+// Answer returns the documents sharing words with the query, best match first
+func (a *KeywordAnswerer) Answer(ctx context.Context, query, languageCode string) ([]Passage, error) {
+	words := strings.Fields(normalizeText(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+	var answers []Passage
+	for _, doc := range a.Documents {
+		if doc.Language != "" && !strings.HasPrefix(strings.ToLower(languageCode), strings.ToLower(doc.Language)) {
+			continue
+		}
+		docWords := wordSet(doc.Title + " " + doc.Body + " " + strings.Join(doc.Keywords, " "))
+		found := 0
+		for _, word := range words {
+			if docWords[word] {
+				found++
+			}
+		}
+		if found == 0 {
+			continue
+		}
+		answers = append(answers, Passage{
+			Title:   doc.Title,
+			Snippet: bestSentence(doc.Body, words),
+			URL:     doc.URL,
+			Score:   float64(found) / float64(len(words)),
+		})
+	}
+	sort.SliceStable(answers, func(i, j int) bool {
+		return answers[i].Score > answers[j].Score
+	})
+	return answers, nil
+}
+
+// wordSet returns the normalized words of a text
+func wordSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(normalizeText(text)) {
+		set[word] = true
+	}
+	return set
+}
+
+// bestSentence returns the sentence of the text containing the most words,
+// followed by the next sentence for context
+func bestSentence(text string, words []string) string {
+	sentences := splitSentences(text)
+	best, bestCount := 0, -1
+	for i, sentence := range sentences {
+		set, count := wordSet(sentence), 0
+		for _, word := range words {
+			if set[word] {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+	if best+1 < len(sentences) {
+		return sentences[best] + " " + sentences[best+1]
+	}
+	if len(sentences) == 0 {
+		return ""
+	}
+	return sentences[best]
+}
+
+// sentenceEnd matches the end of a sentence
+var sentenceEnd = regexp.MustCompile(`[.!?]\s+`)
+
+// splitSentences splits a text into sentences
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		sentences = append(sentences, strings.TrimSpace(text[start:loc[0]+1]))
+		start = loc[1]
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// OpenSearchAnswerer searches an OpenSearch or Elasticsearch index with a
+// multi_match query. Amazon OpenSearch Service domains requiring signed
+// requests are accessed with a signing transport set on the HTTPClient.
+// Within KnowledgeFallback the searches are counted for AccountUsage and
+// captured in dry runs, see For.
+type OpenSearchAnswerer struct {
+	// Endpoint is the base url of the cluster
+	Endpoint string
+	Index    string
+	// TitleField, BodyField and URLField default to "title", "body" and "url"
+	TitleField string
+	BodyField  string
+	URLField   string
+	// LanguageField filters the documents by language if set
+	LanguageField string
+	Size          int
+	HTTPClient    *http.Client
+}
+
+// defaultSearchClient is used by OpenSearchAnswerer without a HTTPClient
+var defaultSearchClient = &http.Client{Timeout: 5 * time.Second}
+
+// For returns a copy of the answerer sending its searches like the calls of
+// Client.For: counted as downstream calls of the turn and captured in dry runs
+func (a *OpenSearchAnswerer) For(w *Agent) Answerer {
+	bound := *a
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultSearchClient
+	}
+	bound.HTTPClient = w.httpClient(httpClient)
+	return &bound
+}
+
+// Answer runs the search and returns the hits with their highlighted body
+func (a *OpenSearchAnswerer) Answer(ctx context.Context, query, languageCode string) ([]Passage, error) {
+	titleField, bodyField, urlField := a.TitleField, a.BodyField, a.URLField
+	if titleField == "" {
+		titleField = "title"
+	}
+	if bodyField == "" {
+		bodyField = "body"
+	}
+	if urlField == "" {
+		urlField = "url"
+	}
+	size := a.Size
+	if size <= 0 {
+		size = 3
+	}
+
+	var search interface{} = map[string]interface{}{
+		"multi_match": map[string]interface{}{"query": query, "fields": []string{titleField + "^2", bodyField}},
+	}
+	if a.LanguageField != "" {
+		search = map[string]interface{}{"bool": map[string]interface{}{
+			"must":   search,
+			"filter": map[string]interface{}{"prefix": map[string]interface{}{a.LanguageField: strings.ToLower(strings.SplitN(languageCode, "-", 2)[0])}},
+		}}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"size":  size,
+		"query": search,
+		"highlight": map[string]interface{}{
+			"fields": map[string]interface{}{bodyField: map[string]interface{}{"number_of_fragments": 1, "fragment_size": 300}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(a.Endpoint, "/")+"/"+a.Index+"/_search", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultSearchClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search returned %v: %s", resp.Status, b)
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Score     float64                `json:"_score"`
+				Source    map[string]interface{} `json:"_source"`
+				Highlight map[string][]string    `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("unable to parse search result: %v", err)
+	}
+	answers := make([]Passage, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		answer := Passage{Score: hit.Score}
+		answer.Title, _ = hit.Source[titleField].(string)
+		answer.URL, _ = hit.Source[urlField].(string)
+		if fragments := hit.Highlight[bodyField]; len(fragments) > 0 {
+			answer.Snippet = fragments[0]
+		} else {
+			answer.Snippet, _ = hit.Source[bodyField].(string)
+		}
+		answers = append(answers, answer)
+	}
+	return answers, nil
+}
@@ -0,0 +1,56 @@
+package lambdadialogflow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestKnowledgeAnswers(t *testing.T) {
+	w, err := Parse([]byte(`{
+		"responseId": "1",
+		"session": "projects/p/agent/sessions/s",
+		"queryResult": {
+			"queryText": "opening hours",
+			"knowledgeAnswers": {"answers": [{"answer": "9 to 5", "matchConfidence": 0.9}]}
+		},
+		"alternativeQueryResults": [{
+			"knowledgeAnswers": {"answers": [{"answer": "closed on sundays", "matchConfidenceLevel": "LOW"}]}
+		}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	answers := w.KnowledgeAnswers()
+	if len(answers) != 2 || answers[0].Answer != "9 to 5" || answers[0].MatchConfidence != 0.9 ||
+		answers[1].MatchConfidenceLevel != "LOW" {
+		t.Errorf("KnowledgeAnswers = %+v", answers)
+	}
+	if got := len(w.AlternativeResults()); got != 1 {
+		t.Errorf("AlternativeResults = %v, want 1", got)
+	}
+}
+
+func TestOpenSearchAnswererDryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t.Errorf("search sent in dry run: %v", req.URL)
+	}))
+	defer server.Close()
+
+	r := NewRouter()
+	fallback := &KnowledgeFallback{Answerer: &OpenSearchAnswerer{Endpoint: server.URL, Index: "faq"}}
+	r.NotFound(fallback.Handler())
+	resp, err := r.serve(context.Background(), events.APIGatewayProxyRequest{
+		Body: `{"responseId":"1","session":"projects/p/agent/sessions/s","queryResult":{"queryText":"opening hours"}}`,
+	}, serveOptions{dryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Body, `"dryRun"`) || !strings.Contains(resp.Body, "/faq/_search") {
+		t.Errorf("search not captured: %v", resp.Body)
+	}
+}
@@ -3,28 +3,49 @@ package lambdadialogflow
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
-	"fmt"
-	"strings"
+	"math/rand"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/golang/protobuf/jsonpb"
 	_structpb "github.com/golang/protobuf/ptypes/struct"
 	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
 )
 
 // Agent contains the original dialogflow request and convenient methods to construct a response
 type Agent struct {
-	req *df.WebhookRequest
-	res *df.WebhookResponse
+	req          *df.WebhookRequest
+	res          *df.WebhookResponse
+	alternatives []*df.QueryResult
+	knowledge    []KnowledgeAnswer
+	sessionData  map[string]interface{}
+	sessionDirty bool
+	failure      ErrorClass
+	events       []Event
+	noCache      bool
+	cacheHit     bool
+	authRequired bool
+	dryRun       bool
+	sideEffects  []SideEffect
+	router       *Router
+	ctx          context.Context
+	handlerErr   error
+	panicked     bool
+	calls        int
+	rnd          *rand.Rand
+	queue        []*df.Intent_Message
+	profile      interface{}
+	usage        *turnUsage
 }
 
 // WebhookHandler handles one dialogflow request
 type WebhookHandler func(*Agent)
 
 var (
-	handlerMap = make(map[string]WebhookHandler)
+	defaultRouter   = NewRouter()
+	gzipThreshold   = 0
+	errorPolicy     *ErrorPolicy
+	errorPayloadKey string
 )
 
 // Request returns the  dialogflow request
@@ -39,20 +60,45 @@ func (w *Agent) Response() *df.WebhookResponse {
 
 // Action returns the action from the dialogflow request
 func (w *Agent) Action() string {
-	return w.req.QueryResult.Action
+	return w.req.GetQueryResult().GetAction()
 }
 
 // Session returns the session id for this request
 func (w *Agent) Session() string {
-	return w.req.Session
+	return w.req.GetSession()
+}
+
+// Query returns the text the user said or typed
+func (w *Agent) Query() string {
+	return w.req.GetQueryResult().GetQueryText()
+}
+
+// Language returns the language code of the request, e.g. "en-us"
+func (w *Agent) Language() string {
+	return w.req.GetQueryResult().GetLanguageCode()
+}
+
+// Confidence returns the intent detection confidence between 0 and 1
+func (w *Agent) Confidence() float32 {
+	return w.req.GetQueryResult().GetIntentDetectionConfidence()
+}
+
+// IntentName returns the resource name of the matched intent
+func (w *Agent) IntentName() string {
+	return w.req.GetQueryResult().GetIntent().GetName()
+}
+
+// IntentDisplayName returns the display name of the matched intent
+func (w *Agent) IntentDisplayName() string {
+	return w.req.GetQueryResult().GetIntent().GetDisplayName()
 }
 
 func (w *Agent) getField(name string) *_structpb.Value {
-	f := w.req.QueryResult.Parameters.GetFields()[name]
+	f := w.req.GetQueryResult().GetParameters().GetFields()[name]
 	if f != nil {
 		return f
 	}
-	return w.req.OriginalDetectIntentRequest.Payload.GetFields()[name]
+	return w.req.GetOriginalDetectIntentRequest().GetPayload().GetFields()[name]
 }
 
 // GetStringParam returns a string parameter
@@ -80,13 +126,17 @@ func (w *Agent) AddPayload(name, value string) {
 			StringValue: value,
 		},
 	}
+	w.addPayloadValue(name, stringValue)
+}
 
+// addPayloadValue adds a value to the response payload, keeping all other fields
+func (w *Agent) addPayloadValue(name string, value *_structpb.Value) {
 	if w.Response().Payload != nil && w.Response().Payload.Fields != nil {
-		w.Response().Payload.Fields[name] = stringValue
+		w.Response().Payload.Fields[name] = value
 	} else {
 		w.Response().Payload = &_structpb.Struct{
 			Fields: map[string]*_structpb.Value{
-				name: stringValue,
+				name: value,
 			},
 		}
 	}
@@ -105,43 +155,78 @@ func (w *Agent) Say(someText string) {
 
 // SetContext is used to set the output context
 func (w *Agent) SetContext(contextname string, lifetime int32) {
-	ctx := &df.Context{Name: contextname, LifespanCount: lifetime}
-	w.res.OutputContexts = append(w.res.OutputContexts, ctx)
+	w.OutputContext(contextname).SetLifespan(lifetime)
+}
+
+// SetFollowupEvent makes dialogflow trigger the intent listening on the event
+// instead of replying, e.g. to move on after a validation failed. An empty
+// language code uses the language of the request.
+func (w *Agent) SetFollowupEvent(name string, params map[string]interface{}, languageCode string) {
+	if languageCode == "" {
+		languageCode = w.req.GetQueryResult().GetLanguageCode()
+	}
+	w.res.FollowupEventInput = &df.EventInput{
+		Name:         name,
+		Parameters:   toValue(params).GetStructValue(),
+		LanguageCode: languageCode,
+	}
 }
 
 // Register a new webhook handler for an action
 func Register(action string, handler WebhookHandler) {
-	handlerMap[action] = handler
+	defaultRouter.Register(action, handler)
+}
+
+// RegisterIntent registers a webhook handler for an intent by its display
+// name. It is used for requests without a handler for their action.
+func RegisterIntent(displayName string, handler WebhookHandler) {
+	defaultRouter.RegisterIntent(displayName, handler)
+}
+
+// NotFound sets the handler for requests without a registered handler
+func NotFound(handler WebhookHandler) {
+	defaultRouter.NotFound(handler)
+}
+
+// RegisterDefault is an alias of NotFound. It registers a catch-all handler
+// for unmatched actions, so users get a graceful reply instead of dialogflow's
+// "webhook call failed". The handler can inspect the action and intent of the
+// request as usual.
+func RegisterDefault(handler WebhookHandler) {
+	defaultRouter.RegisterDefault(handler)
+}
+
+// Use adds middlewares running around every handler of the default router
+func Use(middlewares ...Middleware) {
+	defaultRouter.Use(middlewares...)
+}
+
+// Transform adds response transformers to the default router
+func Transform(transformers ...ResponseTransformer) {
+	defaultRouter.Transform(transformers...)
 }
 
 // newAgent creates a new agent based on the webhook request from dialogflow
 func newAgent(webhookRequest *df.WebhookRequest) (*Agent, error) {
-	w := &Agent{req: webhookRequest, res: &df.WebhookResponse{}}
+	w := &Agent{req: webhookRequest, res: &df.WebhookResponse{}, router: defaultRouter}
 	return w, nil
 }
 
 // HandleRequest handles the dialogflow request coming in via the lambda api gateway
 func HandleRequest(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	webhookRequest := &df.WebhookRequest{}
-	err := jsonpb.Unmarshal(strings.NewReader(req.Body), webhookRequest)
-	if err != nil {
-		return events.APIGatewayProxyResponse{StatusCode: 400},
-			fmt.Errorf("unable to decode webhook request: %v", err)
-	}
-
-	w, err := newAgent(webhookRequest)
-
-	webhookHandler := handlerMap[w.Action()]
-	if webhookHandler == nil {
-		return events.APIGatewayProxyResponse{StatusCode: 404},
-			fmt.Errorf("no handler defined for action: %v", w.Action())
-	}
-
-	webhookHandler(w)
+	return defaultRouter.Serve(req)
+}
 
+// marshalResponse marshals the webhook response into the response body
+func marshalResponse(res *df.WebhookResponse) (string, error) {
 	var buf bytes.Buffer
-	marshaler := &jsonpb.Marshaler{}
-	err = marshaler.Marshal(&buf, w.res)
+	err := responseMarshaler().Marshal(&buf, res)
+	return buf.String(), err
+}
+
+// respond marshals the webhook response into the api gateway response
+func respond(req events.APIGatewayProxyRequest, res *df.WebhookResponse) (events.APIGatewayProxyResponse, error) {
+	body, err := marshalResponse(res)
 	if err != nil {
 		return events.APIGatewayProxyResponse{StatusCode: 500}, err
 	}
@@ -149,15 +234,18 @@ func HandleRequest(req events.APIGatewayProxyRequest) (events.APIGatewayProxyRes
 	resp := events.APIGatewayProxyResponse{
 		StatusCode:      200,
 		IsBase64Encoded: false,
-		Body:            buf.String(),
+		Body:            body,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
 	}
+	if err := compressResponse(req, &resp); err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 500}, err
+	}
 	return resp, err
 }
 
 // Start listening on requests
 func Start() {
-	lambda.Start(HandleRequest)
+	defaultRouter.Start()
 }
@@ -0,0 +1,130 @@
+package lambdadialogflow
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// textLimit is the maximum length of one text message on a platform and
+// whether longer texts can be split into several messages
+type textLimit struct {
+	platform df.Intent_Message_Platform
+	length   int
+	split    bool
+}
+
+// textLimits maps the request sources onto the text limits of their platforms
+var textLimits = map[Source]textLimit{
+	SourceFacebookMessenger: {platform: df.Intent_Message_FACEBOOK, length: 640, split: true},
+	SourceTelegram:          {platform: df.Intent_Message_TELEGRAM, length: 4096, split: true},
+	SourceSlack:             {platform: df.Intent_Message_SLACK, length: 3000, split: true},
+	SourceViber:             {platform: df.Intent_Message_VIBER, length: 7000, split: true},
+	SourceGoogleAssistant:   {platform: df.Intent_Message_ACTIONS_ON_GOOGLE, length: 640},
+}
+
+var enforceTextLimits = false
+
+// EnableTextLimits splits or truncates texts exceeding the limits of the platform
+// the request came from. Platforms showing several bubbles get the text split
+// into several messages, others get it truncated.
+func EnableTextLimits() {
+	enforceTextLimits = true
+}
+
+// applyTextLimits fits the texts of the response into the limits of the platform
+func (w *Agent) applyTextLimits() {
+	if !enforceTextLimits {
+		return
+	}
+	limit, ok := textLimits[w.Source()]
+	if !ok {
+		return
+	}
+
+	if utf8.RuneCountInString(w.res.FulfillmentText) > limit.length {
+		if limit.split && len(w.res.FulfillmentMessages) == 0 {
+			for _, part := range splitText(w.res.FulfillmentText, limit.length) {
+				w.AddMessage(&df.Intent_Message{
+					Platform: limit.platform,
+					Message:  &df.Intent_Message_Text_{Text: &df.Intent_Message_Text{Text: []string{part}}},
+				})
+			}
+		}
+		w.res.FulfillmentText = truncateText(w.res.FulfillmentText, limit.length)
+	}
+
+	var msgs []*df.Intent_Message
+	for _, msg := range w.res.FulfillmentMessages {
+		if msg.Platform != limit.platform && msg.Platform != df.Intent_Message_PLATFORM_UNSPECIFIED {
+			msgs = append(msgs, msg)
+			continue
+		}
+		switch m := msg.Message.(type) {
+		case *df.Intent_Message_Text_:
+			for _, text := range m.Text.Text {
+				if !limit.split {
+					msgs = append(msgs, textMessage(msg.Platform, truncateText(text, limit.length)))
+					continue
+				}
+				for _, part := range splitText(text, limit.length) {
+					msgs = append(msgs, textMessage(msg.Platform, part))
+				}
+			}
+		case *df.Intent_Message_SimpleResponses_:
+			for _, r := range m.SimpleResponses.SimpleResponses {
+				r.DisplayText = truncateText(r.DisplayText, limit.length)
+				r.TextToSpeech = truncateText(r.TextToSpeech, limit.length)
+			}
+			msgs = append(msgs, msg)
+		default:
+			msgs = append(msgs, msg)
+		}
+	}
+	w.res.FulfillmentMessages = msgs
+}
+
+// textMessage creates a text message for a platform
+func textMessage(platform df.Intent_Message_Platform, text string) *df.Intent_Message {
+	return &df.Intent_Message{
+		Platform: platform,
+		Message:  &df.Intent_Message_Text_{Text: &df.Intent_Message_Text{Text: []string{text}}},
+	}
+}
+
+// splitText splits a text into parts of at most length runes, preferably at the
+// end of a sentence or else at a space
+func splitText(text string, length int) []string {
+	var parts []string
+	for utf8.RuneCountInString(text) > length {
+		runes := []rune(text)
+		head := string(runes[:length])
+		cut := strings.LastIndexAny(head, ".!?\n")
+		if cut < len(head)/2 {
+			cut = strings.LastIndex(head, " ")
+		}
+		if cut <= 0 {
+			cut = len(head) - 1
+		}
+		parts = append(parts, strings.TrimSpace(head[:cut+1]))
+		text = strings.TrimSpace(text[cut+1:])
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
+
+// truncateText shortens a text to at most length runes, ending with an ellipsis
+func truncateText(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	head := string(runes[:length-1])
+	if cut := strings.LastIndex(head, " "); cut > len(head)/2 {
+		head = head[:cut]
+	}
+	return head + "…"
+}
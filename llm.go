@@ -0,0 +1,207 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// llmResponseReserve is the part of the request deadline left for building
+// and sending the response after the model answered
+const llmResponseReserve = 500 * time.Millisecond
+
+// LLMFallback answers queries no intent handler matches confidently with a
+// language model behind an OpenAI compatible chat completions endpoint, e.g.
+// OpenAI, vLLM or a Bedrock access gateway.
+type LLMFallback struct {
+	// Endpoint is the url of the chat completions api
+	Endpoint string
+	APIKey   string
+	Model    string
+	// SystemPrompt is a text/template executed with LLMPromptData
+	SystemPrompt string
+	// MaxTokens limits the length of the answer, defaults to 256
+	MaxTokens int
+	// Timeout of the model call, defaults to 3s. It is shortened to stay
+	// within the deadline of the webhook request.
+	Timeout time.Duration
+	// MinConfidence is the intent detection confidence below which matched
+	// intents are answered by the model when used as middleware
+	MinConfidence float32
+	// HistoryTurns previous turns of the conversation log are sent along
+	HistoryTurns int
+	// Unavailable is said when the model fails or times out
+	Unavailable string
+	HTTPClient  *http.Client
+}
+
+// LLMPromptData is the data the system prompt template is executed with
+type LLMPromptData struct {
+	Query    string
+	Language string
+	Intent   string
+	Snapshot Snapshot
+	// SnapshotJSON is the JSON encoded snapshot
+	SnapshotJSON string
+}
+
+// Handler returns a handler answering every query with the model, e.g. to be
+// set with NotFound or registered for the action of the fallback intent
+func (f *LLMFallback) Handler() WebhookHandler {
+	return func(w *Agent) {
+		answer, err := f.Answer(w)
+		if err != nil {
+			log.Printf("unable to answer with language model: %v", err)
+			answer = f.Unavailable
+		}
+		w.Say(answer)
+	}
+}
+
+// Middleware returns a middleware answering with the model instead of the
+// handler when the matched intent is a fallback intent or its confidence is
+// below MinConfidence
+func (f *LLMFallback) Middleware() Middleware {
+	return func(handler WebhookHandler) WebhookHandler {
+		return func(w *Agent) {
+			result := w.req.GetQueryResult()
+			if !result.GetIntent().GetIsFallback() && result.GetIntentDetectionConfidence() >= f.MinConfidence {
+				handler(w)
+				return
+			}
+			f.Handler()(w)
+		}
+	}
+}
+
+// llmMessage is a message of a chat completions request
+type llmMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Answer asks the model to answer the query of the request
+func (f *LLMFallback) Answer(w *Agent) (string, error) {
+	prompt, err := f.systemPrompt(w)
+	if err != nil {
+		return "", err
+	}
+	messages := []llmMessage{{Role: "system", Content: prompt}}
+	if f.HistoryTurns > 0 && conversationLog != nil {
+		turns, err := w.History(f.HistoryTurns)
+		if err != nil {
+			return "", fmt.Errorf("unable to load history: %v", err)
+		}
+		for i := len(turns) - 1; i >= 0; i-- {
+			messages = append(messages,
+				llmMessage{Role: "user", Content: turns[i].QueryText},
+				llmMessage{Role: "assistant", Content: turns[i].Response})
+		}
+	}
+	messages = append(messages, llmMessage{Role: "user", Content: w.req.GetQueryResult().GetQueryText()})
+
+	maxTokens := f.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 256
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"model":      f.Model,
+		"messages":   messages,
+		"max_tokens": maxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(w.Context(), f.timeout(w.Context()))
+	defer cancel()
+	req, err := http.NewRequest("POST", f.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if f.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+f.APIKey)
+	}
+
+	resp, err := f.client(w).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("language model returned %v: %s", resp.Status, b)
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message llmMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(b, &completion); err != nil {
+		return "", fmt.Errorf("unable to parse completion: %v", err)
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return "", errors.New("language model returned no answer")
+	}
+	return strings.TrimSpace(completion.Choices[0].Message.Content), nil
+}
+
+// systemPrompt executes the system prompt template for the request
+func (f *LLMFallback) systemPrompt(w *Agent) (string, error) {
+	tmpl, err := template.New("system").Parse(f.SystemPrompt)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse system prompt: %v", err)
+	}
+	snapshot := w.Snapshot()
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	var prompt strings.Builder
+	err = tmpl.Execute(&prompt, LLMPromptData{
+		Query:        w.req.GetQueryResult().GetQueryText(),
+		Language:     w.req.GetQueryResult().GetLanguageCode(),
+		Intent:       w.req.GetQueryResult().GetIntent().GetDisplayName(),
+		Snapshot:     snapshot,
+		SnapshotJSON: string(snapshotJSON),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to execute system prompt: %v", err)
+	}
+	return prompt.String(), nil
+}
+
+// timeout returns the timeout of the model call, leaving time for the
+// response before the deadline of ctx
+func (f *LLMFallback) timeout(ctx context.Context) time.Duration {
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline) - llmResponseReserve; left < timeout {
+			timeout = left
+		}
+	}
+	return timeout
+}
+
+// client returns the http client for the model call, counting the call for
+// AccountUsage and capturing it in dry runs
+func (f *LLMFallback) client(w *Agent) *http.Client {
+	return w.httpClient(f.HTTPClient)
+}
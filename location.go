@@ -0,0 +1,39 @@
+package lambdadialogflow
+
+// Location is a @sys.location parameter. Dialogflow only fills the parts the
+// user mentioned. Coordinates are not resolved by dialogflow, they are set
+// if an integration sends latitude and longitude with the parameter.
+type Location struct {
+	Country      string
+	City         string
+	AdminArea    string
+	SubadminArea string
+	Street       string
+	ZipCode      string
+	BusinessName string
+	Island       string
+	Shortcut     string
+	Latitude     float64
+	Longitude    float64
+}
+
+// GetLocationParam returns a @sys.location parameter
+func (w *Agent) GetLocationParam(name string) (Location, bool) {
+	fields := w.getField(name).GetStructValue().GetFields()
+	if len(fields) == 0 {
+		return Location{}, false
+	}
+	return Location{
+		Country:      fields["country"].GetStringValue(),
+		City:         fields["city"].GetStringValue(),
+		AdminArea:    fields["admin-area"].GetStringValue(),
+		SubadminArea: fields["subadmin-area"].GetStringValue(),
+		Street:       fields["street-address"].GetStringValue(),
+		ZipCode:      fields["zip-code"].GetStringValue(),
+		BusinessName: fields["business-name"].GetStringValue(),
+		Island:       fields["island"].GetStringValue(),
+		Shortcut:     fields["shortcut"].GetStringValue(),
+		Latitude:     fields["latitude"].GetNumberValue(),
+		Longitude:    fields["longitude"].GetNumberValue(),
+	}, true
+}
@@ -0,0 +1,56 @@
+package lambdadialogflow
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+var (
+	markdownBold = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownLink = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownItem = regexp.MustCompile(`(?m)^[ \t]*[-*][ \t]+`)
+)
+
+// MarkdownToPlain converts the markdown subset (bold, links, lists) into plain text
+func MarkdownToPlain(md string) string {
+	text := markdownItem.ReplaceAllString(md, "• ")
+	text = markdownBold.ReplaceAllString(text, "$1")
+	return markdownLink.ReplaceAllString(text, "$1 ($2)")
+}
+
+// MarkdownToTelegramHTML converts the markdown subset into telegram's HTML formatting
+func MarkdownToTelegramHTML(md string) string {
+	text := markdownItem.ReplaceAllString(html.EscapeString(md), "• ")
+	text = markdownBold.ReplaceAllString(text, "<b>$1</b>")
+	return markdownLink.ReplaceAllStringFunc(text, func(link string) string {
+		m := markdownLink.FindStringSubmatch(link)
+		return `<a href="` + m[2] + `">` + m[1] + `</a>`
+	})
+}
+
+// MarkdownToSlack converts the markdown subset into slack's mrkdwn formatting
+func MarkdownToSlack(md string) string {
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	text := markdownItem.ReplaceAllString(escaper.Replace(md), "• ")
+	text = markdownBold.ReplaceAllString(text, "*$1*")
+	return markdownLink.ReplaceAllString(text, "<$2|$1>")
+}
+
+// SayMarkdown lets the agent return a message written in a small markdown
+// subset (**bold**, [links](url) and "- " lists). The message is formatted for
+// the platform the request came from and as plain text everywhere else.
+func (w *Agent) SayMarkdown(md string) {
+	w.Say(MarkdownToPlain(md))
+	switch w.Source() {
+	case SourceTelegram:
+		w.AddPlatformPayload(df.Intent_Message_TELEGRAM, "telegram", map[string]interface{}{
+			"text":       MarkdownToTelegramHTML(md),
+			"parse_mode": "HTML",
+		})
+	case SourceSlack:
+		w.AddMessage(textMessage(df.Intent_Message_SLACK, MarkdownToSlack(md)))
+	}
+}
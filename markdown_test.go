@@ -0,0 +1,11 @@
+package lambdadialogflow
+
+import "testing"
+
+func TestMarkdownToPlainLists(t *testing.T) {
+	md := "Your options:\n\n- **pizza**\n  * pasta\n-\nnot an item"
+	want := "Your options:\n\n• pizza\n• pasta\n-\nnot an item"
+	if got := MarkdownToPlain(md); got != want {
+		t.Errorf("MarkdownToPlain = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package lambdadialogflow
+
+import (
+	"sort"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// SetSource sets the source of the webhook response
+func (w *Agent) SetSource(source string) {
+	w.res.Source = source
+}
+
+// AddMessage appends a fulfillment message to the response
+func (w *Agent) AddMessage(msg *df.Intent_Message) {
+	w.res.FulfillmentMessages = append(w.res.FulfillmentMessages, msg)
+}
+
+// InsertMessage inserts a fulfillment message at the given position,
+// positions beyond the end append the message
+func (w *Agent) InsertMessage(index int, msg *df.Intent_Message) {
+	msgs := w.res.FulfillmentMessages
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(msgs) {
+		w.AddMessage(msg)
+		return
+	}
+	msgs = append(msgs, nil)
+	copy(msgs[index+1:], msgs[index:])
+	msgs[index] = msg
+	w.res.FulfillmentMessages = msgs
+}
+
+// SortMessages orders the fulfillment messages, messages comparing equal keep their order
+func (w *Agent) SortMessages(less func(a, b *df.Intent_Message) bool) {
+	msgs := w.res.FulfillmentMessages
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return less(msgs[i], msgs[j])
+	})
+}
+
+// TextFirst orders text messages before rich messages when used with SortMessages
+func TextFirst(a, b *df.Intent_Message) bool {
+	return isTextMessage(a) && !isTextMessage(b)
+}
+
+// TextLast orders text messages after rich messages when used with SortMessages
+func TextLast(a, b *df.Intent_Message) bool {
+	return !isTextMessage(a) && isTextMessage(b)
+}
+
+// isTextMessage reports whether the message is plain text or a simple response
+func isTextMessage(msg *df.Intent_Message) bool {
+	switch msg.GetMessage().(type) {
+	case *df.Intent_Message_Text_, *df.Intent_Message_SimpleResponses_:
+		return true
+	}
+	return false
+}
+
+// SayWithSSML lets the agent return a message with separate display text and
+// SSML, so screens show readable text while speakers use the SSML. Actions on
+// google requires a simple response as first item, so it is inserted before
+// cards and other rich messages added earlier.
+func (w *Agent) SayWithSSML(text, ssml string) {
+	w.Say(text)
+	index := len(w.res.FulfillmentMessages)
+	for i, msg := range w.res.FulfillmentMessages {
+		if _, ok := msg.GetMessage().(*df.Intent_Message_SimpleResponses_); msg.Platform == df.Intent_Message_ACTIONS_ON_GOOGLE && !ok {
+			index = i
+			break
+		}
+	}
+	w.InsertMessage(index, simpleResponse(&df.Intent_Message_SimpleResponse{
+		Ssml:        ssml,
+		DisplayText: text,
+	}))
+}
+
+// simpleResponse wraps a simple response into an actions on google message
+func simpleResponse(response *df.Intent_Message_SimpleResponse) *df.Intent_Message {
+	return &df.Intent_Message{
+		Platform: df.Intent_Message_ACTIONS_ON_GOOGLE,
+		Message: &df.Intent_Message_SimpleResponses_{
+			SimpleResponses: &df.Intent_Message_SimpleResponses{
+				SimpleResponses: []*df.Intent_Message_SimpleResponse{response},
+			},
+		},
+	}
+}
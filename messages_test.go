@@ -0,0 +1,27 @@
+package lambdadialogflow
+
+import (
+	"testing"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+func TestSayWithSSMLFirst(t *testing.T) {
+	w := testAgent(t, `{"action":"menu"}`)
+	w.AddSuggestions("Pizza", "Pasta")
+	w.SayWithSSML("Pick one", "<speak>Pick one</speak>")
+	w.SayWithSSML("or ask me", "<speak>or ask me</speak>")
+
+	msgs := w.Response().FulfillmentMessages
+	if len(msgs) != 3 {
+		t.Fatalf("messages = %v", msgs)
+	}
+	for i, want := range []string{"Pick one", "or ask me"} {
+		if got := msgs[i].GetSimpleResponses().GetSimpleResponses()[0].GetDisplayText(); got != want {
+			t.Errorf("message %v = %q, want simple response %q", i, got, want)
+		}
+	}
+	if _, ok := msgs[2].GetMessage().(*df.Intent_Message_Suggestions_); !ok {
+		t.Errorf("last message = %v, want suggestions", msgs[2])
+	}
+}
@@ -0,0 +1,111 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// metricDimensions are the dimensions every metric is emitted with
+var metricDimensions = []string{"Intent", "Language", "Platform", "Environment"}
+
+var (
+	metricsNamespace   string
+	metricsEnvironment string
+	metricsWriter      io.Writer = os.Stdout
+)
+
+// EnableMetrics emits request metrics in CloudWatch embedded metric format,
+// dimensioned by intent, language, platform and environment, e.g. the stage
+// the function is deployed to. Lambda forwards the lines printed to stdout to
+// CloudWatch, which extracts the metrics without any API calls.
+func EnableMetrics(namespace, environment string) {
+	metricsNamespace = namespace
+	metricsEnvironment = environment
+}
+
+// metric is one value of an embedded metric format record
+type metric struct {
+	Name  string  `json:"Name"`
+	Unit  string  `json:"Unit"`
+	value float64 // written as a top level field of the record
+}
+
+// emitMetrics writes one embedded metric format record with the given
+// metrics, dimensioned by the request
+func (w *Agent) emitMetrics(metrics ...metric) {
+	writeMetrics(
+		w.req.GetQueryResult().GetIntent().GetDisplayName(),
+		w.req.GetQueryResult().GetLanguageCode(),
+		w.req.GetOriginalDetectIntentRequest().GetSource(),
+		metrics...,
+	)
+}
+
+// writeMetrics writes one embedded metric format record with the given
+// metrics and dimensions, e.g. for requests which could not be parsed
+func writeMetrics(intent, language, platform string, metrics ...metric) {
+	if metricsNamespace == "" {
+		return
+	}
+
+	record := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": time.Now().UnixNano() / int64(time.Millisecond),
+			"CloudWatchMetrics": []interface{}{map[string]interface{}{
+				"Namespace":  metricsNamespace,
+				"Dimensions": [][]string{metricDimensions},
+				"Metrics":    metrics,
+			}},
+		},
+		"Intent":      intent,
+		"Language":    language,
+		"Platform":    platform,
+		"Environment": metricsEnvironment,
+	}
+	if record["Platform"] == "" {
+		record["Platform"] = "unknown"
+	}
+	// requests without an intent or language, e.g. unsupported requests,
+	// share one series instead of an empty dimension value
+	if record["Intent"] == "" {
+		record["Intent"] = "none"
+	}
+	if record["Language"] == "" {
+		record["Language"] = "unknown"
+	}
+	for _, m := range metrics {
+		record[m.Name] = m.value
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("unable to encode metrics: %v", err)
+		return
+	}
+	metricsWriter.Write(append(b, '\n'))
+}
+
+// recordRequest emits the metrics of a handled request
+func (w *Agent) recordRequest(start time.Time, status int, err error) {
+	failed := 0.0
+	if err != nil || status >= 400 || w.failure != "" {
+		failed = 1
+	}
+	recordStats(w.req.GetQueryResult().GetIntent().GetDisplayName(), failed == 1)
+	if !w.dryRun {
+		recordHeatmap(w.req.GetQueryResult().GetIntent().GetDisplayName(), time.Now())
+	}
+	fallback := 0.0
+	if w.req.GetQueryResult().GetIntent().GetIsFallback() {
+		fallback = 1
+	}
+	w.emitMetrics(
+		metric{Name: "Requests", Unit: "Count", value: 1},
+		metric{Name: "Errors", Unit: "Count", value: failed},
+		metric{Name: "Fallbacks", Unit: "Count", value: fallback},
+		metric{Name: "Latency", Unit: "Milliseconds", value: float64(time.Since(start)) / float64(time.Millisecond)},
+	)
+}
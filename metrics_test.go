@@ -0,0 +1,25 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestMetricsWithoutIntent(t *testing.T) {
+	var buf bytes.Buffer
+	metricsWriter, metricsNamespace = &buf, "test"
+	defer func() {
+		metricsWriter, metricsNamespace = os.Stdout, ""
+	}()
+
+	writeMetrics("", "", "", metric{Name: "Requests", Unit: "Count", value: 1})
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["Intent"] != "none" || record["Language"] != "unknown" || record["Platform"] != "unknown" {
+		t.Errorf("dimensions = %v, %v, %v", record["Intent"], record["Language"], record["Platform"])
+	}
+}
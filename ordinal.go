@@ -0,0 +1,114 @@
+package lambdadialogflow
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// listContextPrefix prefixes the contexts storing lists presented by PresentList
+const listContextPrefix = "lambdadialogflow-list-"
+
+// GetOrdinalParam returns a @sys.ordinal parameter, e.g. 2 for "the second one"
+func (w *Agent) GetOrdinalParam(name string) int {
+	return int(w.GetNumberParam(name))
+}
+
+// GetNumberSequenceParam returns the digits of a @sys.number-sequence parameter
+func (w *Agent) GetNumberSequenceParam(name string) string {
+	f := w.getField(name)
+	if s := f.GetStringValue(); s != "" {
+		return strings.Replace(s, " ", "", -1)
+	}
+	if n := w.GetNumberParam(name); n != 0 {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return ""
+}
+
+// PresentList stores the items presented to the user in an output context,
+// so the next turn can map an ordinal like "the second one" onto them
+func (w *Agent) PresentList(list string, items []string) {
+	w.OutputContext(stateName(listContextPrefix+list)).
+		SetLifespan(DefaultLifespan).
+		SetParam("items", items)
+}
+
+// PresentedList returns the items which were stored by PresentList in an earlier turn
+func (w *Agent) PresentedList(list string) []string {
+	ctx := w.inputContext(stateName(listContextPrefix + list))
+	var items []string
+	for _, item := range ctx.GetParameters().GetFields()["items"].GetListValue().GetValues() {
+		items = append(items, item.GetStringValue())
+	}
+	return items
+}
+
+// SelectFromList maps the ordinal parameter onto the list stored by PresentList
+// and returns the zero based index and the selected item
+func (w *Agent) SelectFromList(list, ordinalParam string) (int, string, bool) {
+	items := w.PresentedList(list)
+	ordinal := w.GetOrdinalParam(ordinalParam)
+	if ordinal < 1 || ordinal > len(items) {
+		return -1, "", false
+	}
+	return ordinal - 1, items[ordinal-1], true
+}
+
+// NumberRange is an inclusive range of numbers, e.g. "between 3 and 5"
+type NumberRange struct {
+	From float64
+	To   float64
+}
+
+// numberRange matches ranges like "3-5", "3 to 5" or "between 3 and 5"
+var numberRange = regexp.MustCompile(`(?i)(?:\b(?:between|from|zwischen|von)\s+)?(\d+(?:[.,]\d+)?)\s*(?:-|–|\bto\b|\band\b|\bbis\b|\bund\b)\s*(\d+(?:[.,]\d+)?)`)
+
+// ParseNumberRange parses a range like "3-5", "3 to 5" or "between 3 and 5"
+// from a text. Reversed ranges are swapped.
+func ParseNumberRange(text string) (NumberRange, bool) {
+	m := numberRange.FindStringSubmatch(text)
+	if m == nil {
+		return NumberRange{}, false
+	}
+	from, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	if err != nil {
+		return NumberRange{}, false
+	}
+	to, err := strconv.ParseFloat(strings.Replace(m[2], ",", ".", 1), 64)
+	if err != nil {
+		return NumberRange{}, false
+	}
+	if from > to {
+		from, to = to, from
+	}
+	return NumberRange{From: from, To: to}, true
+}
+
+// GetNumberRangeParam returns a number range parameter. It accepts a list of
+// two numbers, e.g. a @sys.number parameter marked as list, a text parsed by
+// ParseNumberRange and a single number as range of one.
+func (w *Agent) GetNumberRangeParam(name string) (NumberRange, bool) {
+	f := w.getField(name)
+	if values := f.GetListValue().GetValues(); len(values) == 2 {
+		from, to := values[0].GetNumberValue(), values[1].GetNumberValue()
+		if from > to {
+			from, to = to, from
+		}
+		return NumberRange{From: from, To: to}, true
+	}
+	if s := f.GetStringValue(); s != "" {
+		return ParseNumberRange(s)
+	}
+	if n, ok := f.GetKind().(*_structpb.Value_NumberValue); ok {
+		return NumberRange{From: n.NumberValue, To: n.NumberValue}, true
+	}
+	return NumberRange{}, false
+}
+
+// Contains reports whether n lies within the range
+func (r NumberRange) Contains(n float64) bool {
+	return n >= r.From && n <= r.To
+}
@@ -0,0 +1,100 @@
+package lambdadialogflow
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParseNumberRange(t *testing.T) {
+	tests := []struct {
+		text string
+		want NumberRange
+		ok   bool
+	}{
+		{"3-5", NumberRange{3, 5}, true},
+		{"3 - 5", NumberRange{3, 5}, true},
+		{"between 3 and 5", NumberRange{3, 5}, true},
+		{"from 10 to 20 euros", NumberRange{10, 20}, true},
+		{"zwischen 2,5 und 4", NumberRange{2.5, 4}, true},
+		{"5 to 3", NumberRange{3, 5}, true},
+		{"3", NumberRange{}, false},
+		{"three to five", NumberRange{}, false},
+		{"", NumberRange{}, false},
+	}
+	for _, test := range tests {
+		got, ok := ParseNumberRange(test.text)
+		if got != test.want || ok != test.ok {
+			t.Errorf("ParseNumberRange(%q) = %v, %v, want %v, %v", test.text, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestGetNumberRangeParam(t *testing.T) {
+	tests := []struct {
+		param string
+		want  NumberRange
+		ok    bool
+	}{
+		{`[5, 3]`, NumberRange{3, 5}, true},
+		{`"between 10 and 20"`, NumberRange{10, 20}, true},
+		{`7`, NumberRange{7, 7}, true},
+		{`"many"`, NumberRange{}, false},
+		{`null`, NumberRange{}, false},
+	}
+	for _, test := range tests {
+		w := testAgent(t, `{"parameters":{"range":`+test.param+`}}`)
+		got, ok := w.GetNumberRangeParam("range")
+		if got != test.want || ok != test.ok {
+			t.Errorf("GetNumberRangeParam(%v) = %v, %v, want %v, %v", test.param, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestGetNumberSequenceParam(t *testing.T) {
+	tests := []struct {
+		param string
+		want  string
+	}{
+		{`"12 34 56"`, "123456"},
+		{`"0049"`, "0049"},
+		{`1234`, "1234"},
+		{`null`, ""},
+	}
+	for _, test := range tests {
+		w := testAgent(t, `{"parameters":{"digits":`+test.param+`}}`)
+		if got := w.GetNumberSequenceParam("digits"); got != test.want {
+			t.Errorf("GetNumberSequenceParam(%v) = %q, want %q", test.param, got, test.want)
+		}
+	}
+}
+
+func TestSelectFromList(t *testing.T) {
+	r := NewRouter()
+	r.Register("list", func(w *Agent) {
+		w.PresentList("pizza.menu", []string{"margherita", "funghi", "diavola"})
+	})
+	r.Register("select", func(w *Agent) {
+		if i, item, ok := w.SelectFromList("pizza.menu", "ordinal"); ok {
+			w.Say(strconv.Itoa(i) + " " + item)
+		} else {
+			w.Say("none")
+		}
+	})
+	c := newConversation(t, r)
+	c.say("list", "menu", nil)
+
+	tests := []struct {
+		ordinal float64
+		want    string
+	}{
+		{1, "0 margherita"},
+		{3, "2 diavola"},
+		{4, "none"},
+		{0, "none"},
+	}
+	for _, test := range tests {
+		if res := c.say("select", "", map[string]interface{}{"ordinal": test.ordinal}); res.FulfillmentText != test.want {
+			t.Errorf("ordinal %v: %q, want %q", test.ordinal, res.FulfillmentText, test.want)
+		}
+	}
+}
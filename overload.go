@@ -0,0 +1,38 @@
+package lambdadialogflow
+
+import df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+
+var (
+	handlerSlots chan struct{}
+	busyText     string
+)
+
+// LimitConcurrency caps the number of handlers executing at the same time.
+// Requests beyond the limit are answered right away with busyText instead of
+// queueing up until dialogflow's webhook timeout. A limit of 0 removes the cap.
+func LimitConcurrency(limit int, text string) {
+	handlerSlots = nil
+	if limit > 0 {
+		handlerSlots = make(chan struct{}, limit)
+	}
+	busyText = text
+}
+
+// acquireSlot reserves a handler slot, it returns false if all slots are taken
+func acquireSlot() (release func(), ok bool) {
+	slots := handlerSlots
+	if slots == nil {
+		return func() {}, true
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
+
+// busyResponse is the response sent when all handler slots are taken
+func busyResponse() *df.WebhookResponse {
+	return &df.WebhookResponse{FulfillmentText: busyText}
+}
@@ -0,0 +1,153 @@
+package lambdadialogflow
+
+import (
+	"strconv"
+	"strings"
+)
+
+// pageContextPrefix prefixes the state of paginated result sets
+const pageContextPrefix = "lambdadialogflow-page-"
+
+// Page is the part of a result set presented in one turn
+type Page struct {
+	Items  []string
+	Number int // starting with 1
+	Count  int
+	Offset int // index of the first item within the result set
+}
+
+// paging is the stored state of a paginated result set
+type paging struct {
+	items []string
+	size  int
+	page  int
+}
+
+var (
+	nextWords     = []string{"next", "more", "continue", "weiter", "mehr"}
+	previousWords = []string{"previous", "back", "before", "zurück", "vorherige"}
+	ordinalWords  = []string{"first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth", "tenth"}
+)
+
+// Paginate stores a result set and returns its first page. The result set is
+// kept in the session store, if configured, and in a context otherwise.
+func (w *Agent) Paginate(name string, items []string, size int) Page {
+	if size < 1 {
+		size = 1
+	}
+	p := paging{items: items, size: size}
+	w.savePaging(name, p)
+	return p.current()
+}
+
+// TurnPage moves to the next or previous page if the user asked for it, e.g.
+// with "more" or "go back", and returns the page to present
+func (w *Agent) TurnPage(name string) (Page, bool) {
+	p, ok := w.loadPaging(name)
+	if !ok {
+		return Page{}, false
+	}
+	text := " " + normalizeText(w.req.GetQueryResult().GetQueryText()) + " "
+	switch {
+	case containsWord(text, nextWords) && p.page+1 < p.pages():
+		p.page++
+	case containsWord(text, previousWords) && p.page > 0:
+		p.page--
+	}
+	w.savePaging(name, p)
+	return p.current(), true
+}
+
+// PageItem maps a selection like "number 3" or "the second one" onto the item
+// of the page presented last
+func (w *Agent) PageItem(name string) (string, bool) {
+	p, ok := w.loadPaging(name)
+	if !ok {
+		return "", false
+	}
+	n := w.selectedNumber()
+	page := p.current()
+	if n < 1 || n > len(page.Items) {
+		return "", false
+	}
+	return page.Items[n-1], true
+}
+
+// selectedNumber returns the number or ordinal mentioned by the user
+func (w *Agent) selectedNumber() int {
+	for _, param := range []string{"ordinal", "number"} {
+		if n := w.GetNumberParam(param); n > 0 {
+			return int(n)
+		}
+	}
+	for _, token := range strings.Fields(normalizeText(w.req.GetQueryResult().GetQueryText())) {
+		if n, err := strconv.Atoi(token); err == nil {
+			return n
+		}
+		for i, word := range ordinalWords {
+			if token == word {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// containsWord reports whether the padded text contains one of the words
+func containsWord(text string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(text, " "+word+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// pages returns the number of pages of the result set
+func (p paging) pages() int {
+	return (len(p.items) + p.size - 1) / p.size
+}
+
+// current returns the current page of the result set
+func (p paging) current() Page {
+	start := p.page * p.size
+	end := start + p.size
+	if end > len(p.items) {
+		end = len(p.items)
+	}
+	if start > end {
+		start = end
+	}
+	return Page{Items: p.items[start:end], Number: p.page + 1, Count: p.pages(), Offset: start}
+}
+
+// loadPaging reads the stored state of a result set
+func (w *Agent) loadPaging(name string) (paging, bool) {
+	data := w.loadState(pageContextPrefix + name)
+	if data == nil {
+		return paging{}, false
+	}
+
+	p := paging{}
+	list, _ := data["items"].([]interface{})
+	for _, item := range list {
+		s, _ := item.(string)
+		p.items = append(p.items, s)
+	}
+	size, _ := data["size"].(float64)
+	page, _ := data["page"].(float64)
+	p.size, p.page = int(size), int(page)
+	if p.size < 1 {
+		p.size = 1
+	}
+	return p, true
+}
+
+// savePaging stores the state of a result set
+func (w *Agent) savePaging(name string, p paging) {
+	items := make([]interface{}, len(p.items))
+	for i, item := range p.items {
+		items[i] = item
+	}
+	w.saveState(pageContextPrefix+name, map[string]interface{}{"items": items, "size": float64(p.size), "page": float64(p.page)})
+}
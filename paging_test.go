@@ -0,0 +1,53 @@
+package lambdadialogflow
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPaging(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	r := NewRouter()
+	r.Register("list", func(w *Agent) {
+		page := w.Paginate("search.results", items, 2)
+		w.Say(strings.Join(page.Items, ","))
+	})
+	r.Register("page", func(w *Agent) {
+		page, ok := w.TurnPage("search.results")
+		if !ok {
+			w.Say("nothing to page")
+			return
+		}
+		w.Say(strings.Join(page.Items, ","))
+	})
+	r.Register("select", func(w *Agent) {
+		item, ok := w.PageItem("search.results")
+		if !ok {
+			w.Say("no such item")
+			return
+		}
+		w.Say(item)
+	})
+	c := newConversation(t, r)
+
+	turns := []struct {
+		action, query string
+		params        map[string]interface{}
+		want          string
+	}{
+		{"page", "more", nil, "nothing to page"},
+		{"list", "show results", nil, "a,b"},
+		{"page", "show me more", nil, "c,d"},
+		{"page", "more", nil, "e"},
+		{"page", "more", nil, "e"},
+		{"page", "go back", nil, "c,d"},
+		{"select", "the second one", nil, "d"},
+		{"select", "number", map[string]interface{}{"number": 1.0}, "c"},
+		{"select", "number 3", nil, "no such item"},
+	}
+	for i, turn := range turns {
+		if res := c.say(turn.action, turn.query, turn.params); res.FulfillmentText != turn.want {
+			t.Errorf("turn %v %q: %q, want %q", i+1, turn.query, res.FulfillmentText, turn.want)
+		}
+	}
+}
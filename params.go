@@ -0,0 +1,250 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// GetBoolParam returns a boolean parameter. String values like "true" are
+// parsed, since custom entities always send strings.
+func (w *Agent) GetBoolParam(name string) bool {
+	f := w.getField(name)
+	if s := f.GetStringValue(); s != "" {
+		b, _ := strconv.ParseBool(s)
+		return b
+	}
+	return f.GetBoolValue()
+}
+
+// GetListParam returns a list parameter, e.g. of an entity marked as "is list"
+func (w *Agent) GetListParam(name string) []interface{} {
+	f := w.getField(name)
+	if f.GetListValue() == nil {
+		return nil
+	}
+	return fromValue(f).([]interface{})
+}
+
+// GetStringListParam returns the string values of a list parameter. A single
+// string value is returned as a list of one.
+func (w *Agent) GetStringListParam(name string) []string {
+	f := w.getField(name)
+	if s := f.GetStringValue(); s != "" {
+		return []string{s}
+	}
+	var values []string
+	for _, value := range f.GetListValue().GetValues() {
+		if s := value.GetStringValue(); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// GetStructParam returns a composite parameter like @sys.unit-currency
+func (w *Agent) GetStructParam(name string) map[string]interface{} {
+	s := w.getField(name).GetStructValue()
+	if s == nil {
+		return nil
+	}
+	return fromStruct(s)
+}
+
+// GetDateParam returns the day of a @sys.date parameter, at midnight in the
+// time zone of the user
+func (w *Agent) GetDateParam(name string) (time.Time, error) {
+	t, err := w.GetDateTimeParam(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t = t.In(w.Location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+}
+
+// GetDateTimeParam returns a @sys.date, @sys.time or @sys.date-time parameter
+func (w *Agent) GetDateTimeParam(name string) (time.Time, error) {
+	f := w.getField(name)
+	value := f.GetStringValue()
+	if value == "" {
+		value = f.GetStructValue().GetFields()["date_time"].GetStringValue()
+	}
+	if value == "" {
+		return time.Time{}, fmt.Errorf("parameter %v is not a date or time", name)
+	}
+	return w.parseTime(value)
+}
+
+// durationUnits are the units of @sys.duration with a fixed length
+var durationUnits = map[string]time.Duration{
+	"ms":  time.Millisecond,
+	"s":   time.Second,
+	"min": time.Minute,
+	"h":   time.Hour,
+	"day": 24 * time.Hour,
+	"wk":  7 * 24 * time.Hour,
+}
+
+// GetDurationParam returns a @sys.duration parameter. Months and years have
+// no fixed length and are rejected.
+func (w *Agent) GetDurationParam(name string) (time.Duration, error) {
+	fields := w.getField(name).GetStructValue().GetFields()
+	if fields == nil {
+		return 0, fmt.Errorf("parameter %v is not a duration", name)
+	}
+	unit, ok := durationUnits[fields["unit"].GetStringValue()]
+	if !ok {
+		return 0, fmt.Errorf("unsupported duration unit: %v", fields["unit"].GetStringValue())
+	}
+	return time.Duration(fields["amount"].GetNumberValue() * float64(unit)), nil
+}
+
+// LookupParam returns the value of a parameter and whether the request has
+// it. Unlike the getters it tells an absent parameter apart from an empty one.
+func (w *Agent) LookupParam(name string) (*_structpb.Value, bool) {
+	f := w.getField(name)
+	switch f.GetKind().(type) {
+	case nil, *_structpb.Value_NullValue:
+		return nil, false
+	}
+	return f, true
+}
+
+// GetStringParamOK returns a string parameter and whether it is present
+func (w *Agent) GetStringParamOK(name string) (string, bool) {
+	f, ok := w.LookupParam(name)
+	if _, isString := f.GetKind().(*_structpb.Value_StringValue); !ok || !isString {
+		return "", false
+	}
+	return f.GetStringValue(), true
+}
+
+// GetNumberParamOK returns a float64 parameter and whether it is present
+func (w *Agent) GetNumberParamOK(name string) (float64, bool) {
+	f, ok := w.LookupParam(name)
+	if _, isNumber := f.GetKind().(*_structpb.Value_NumberValue); !ok || !isNumber {
+		return 0, false
+	}
+	return f.GetNumberValue(), true
+}
+
+// GetBoolParamOK returns a boolean parameter and whether it is present
+func (w *Agent) GetBoolParamOK(name string) (bool, bool) {
+	f, ok := w.LookupParam(name)
+	switch k := f.GetKind().(type) {
+	case *_structpb.Value_BoolValue:
+		return k.BoolValue, ok
+	case *_structpb.Value_StringValue:
+		b, err := strconv.ParseBool(k.StringValue)
+		return b, ok && err == nil
+	}
+	return false, false
+}
+
+// GetListParamOK returns a list parameter and whether it is present
+func (w *Agent) GetListParamOK(name string) ([]interface{}, bool) {
+	f, ok := w.LookupParam(name)
+	if f.GetListValue() == nil || !ok {
+		return nil, false
+	}
+	return fromValue(f).([]interface{}), true
+}
+
+// GetStructParamOK returns a composite parameter and whether it is present
+func (w *Agent) GetStructParamOK(name string) (map[string]interface{}, bool) {
+	f, ok := w.LookupParam(name)
+	if f.GetStructValue() == nil || !ok {
+		return nil, false
+	}
+	return fromStruct(f.GetStructValue()), true
+}
+
+// Params decodes the parameters of the request into v, a pointer to a struct.
+// Fields are matched by their json tags, nested structs and lists of
+// composite entities decode like json. Numbers decode into integer fields if
+// they have no fraction.
+func (w *Agent) Params(v interface{}) error {
+	b, err := json.Marshal(fromStruct(w.req.GetQueryResult().GetParameters()))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("unable to decode parameters: %v", err)
+	}
+	return nil
+}
+
+// Param returns the parameter name as T. The result is false if the parameter
+// is missing, empty as sent by dialogflow for unfilled parameters, or not a T.
+// Dates and times are parsed for time.Time, @sys.duration for time.Duration
+// and @sys.date-period for Period, other types like structs decode like json.
+func Param[T any](w *Agent, name string) (T, bool) {
+	var value T
+	f := w.getField(name)
+	if !hasValue(f) {
+		return value, false
+	}
+
+	var v interface{}
+	var err error
+	switch any(value).(type) {
+	case string:
+		s, ok := f.GetKind().(*_structpb.Value_StringValue)
+		if !ok {
+			return value, false
+		}
+		v = s.StringValue
+	case float64:
+		n, ok := f.GetKind().(*_structpb.Value_NumberValue)
+		if !ok {
+			return value, false
+		}
+		v = n.NumberValue
+	case int:
+		n, ok := f.GetKind().(*_structpb.Value_NumberValue)
+		if !ok {
+			return value, false
+		}
+		v = int(n.NumberValue)
+	case bool:
+		b, ok := f.GetKind().(*_structpb.Value_BoolValue)
+		if !ok {
+			return value, false
+		}
+		v = b.BoolValue
+	case time.Time:
+		v, err = w.GetDateTimeParam(name)
+	case time.Duration:
+		v, err = w.GetDurationParam(name)
+	case Period:
+		v, err = w.GetPeriodParam(name)
+	default:
+		b, err := json.Marshal(fromValue(f))
+		if err != nil || json.Unmarshal(b, &value) != nil {
+			return value, false
+		}
+		return value, true
+	}
+	if err != nil {
+		return value, false
+	}
+	return v.(T), true
+}
+
+// hasValue reports whether a parameter value is set and not empty
+func hasValue(f *_structpb.Value) bool {
+	switch k := f.GetKind().(type) {
+	case nil, *_structpb.Value_NullValue:
+		return false
+	case *_structpb.Value_StringValue:
+		return k.StringValue != ""
+	case *_structpb.Value_ListValue:
+		return len(k.ListValue.GetValues()) > 0
+	case *_structpb.Value_StructValue:
+		return len(k.StructValue.GetFields()) > 0
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// Parse decodes a webhook request into an agent, independent of lambda, e.g.
+// for test fixtures or proxies. The agent uses the default router.
+func Parse(body []byte) (*Agent, error) {
+	fields, err := decodeRawRequest(body)
+	if err != nil {
+		return nil, err
+	}
+	if version := fields.version(); !supportedVersions[version] {
+		return nil, fmt.Errorf("unsupported webhook request version: %v", version)
+	}
+	return parse(body, fields)
+}
+
+// parse decodes a webhook request of a supported version, the top level
+// fields are passed on from the version detection
+func parse(body []byte, fields rawRequest) (*Agent, error) {
+	webhookRequest := &df.WebhookRequest{}
+	unmarshaler := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := unmarshaler.Unmarshal(bytes.NewReader(body), webhookRequest); err != nil {
+		return nil, fmt.Errorf("unable to decode webhook request: %v", err)
+	}
+
+	w, err := newAgent(webhookRequest)
+	if err != nil {
+		return nil, err
+	}
+	beta, err := decodeBeta(fields)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode webhook request: %v", err)
+	}
+	w.alternatives, err = decodeAlternatives(beta)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode webhook request: %v", err)
+	}
+	w.knowledge, err = decodeKnowledgeAnswers(beta)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode webhook request: %v", err)
+	}
+	return w, nil
+}
+
+// ValidateRequest checks that the body is a complete webhook request of a
+// supported version, e.g. to verify recorded fixtures in CI
+func ValidateRequest(body []byte) error {
+	w, err := Parse(body)
+	if err != nil {
+		return err
+	}
+	session := w.Session()
+	if !strings.HasPrefix(session, "projects/") || !strings.Contains(session, "/sessions/") {
+		return fmt.Errorf("invalid session: %q", session)
+	}
+	if w.req.GetQueryResult() == nil {
+		return fmt.Errorf("webhook request without query result")
+	}
+	if w.req.GetResponseId() == "" {
+		return fmt.Errorf("webhook request without response id")
+	}
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		if !strings.HasPrefix(ctx.Name, session+"/contexts/") {
+			return fmt.Errorf("context %v does not belong to session %v", ctx.Name, session)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/jsonpb"
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// SetPayloadMap replaces the response payload with the given map, converting
+// nested maps, slices and primitives into their JSON counterparts
+func (w *Agent) SetPayloadMap(payload map[string]interface{}) {
+	w.res.Payload = toValue(payload).GetStructValue()
+}
+
+// AddPayloadNumber adds a number to the response payload
+func (w *Agent) AddPayloadNumber(name string, value float64) {
+	w.addPayloadValue(name, toValue(value))
+}
+
+// AddPayloadBool adds a boolean to the response payload
+func (w *Agent) AddPayloadBool(name string, value bool) {
+	w.addPayloadValue(name, toValue(value))
+}
+
+// AddPayloadList adds a list to the response payload
+func (w *Agent) AddPayloadList(name string, values []interface{}) {
+	w.addPayloadValue(name, toValue(values))
+}
+
+// AddPayloadStruct adds a nested object to the response payload
+func (w *Agent) AddPayloadStruct(name string, value map[string]interface{}) {
+	w.addPayloadValue(name, toValue(value))
+}
+
+// SetPayloadFrom marshals v honoring its json tags and adds it to the response
+// payload as payload.<namespace>. With an empty namespace the fields of v are
+// merged into the top level of the payload.
+func (w *Agent) SetPayloadFrom(v interface{}, namespace string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to marshal payload: %v", err)
+	}
+	value := &_structpb.Value{}
+	if err := jsonpb.Unmarshal(bytes.NewReader(b), value); err != nil {
+		return fmt.Errorf("unable to convert payload: %v", err)
+	}
+
+	if namespace != "" {
+		w.addPayloadValue(namespace, value)
+		return nil
+	}
+	if value.GetStructValue() == nil {
+		return fmt.Errorf("payload without namespace must be an object, got %T", v)
+	}
+	for name, field := range value.GetStructValue().GetFields() {
+		w.addPayloadValue(name, field)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a time range as sent for @sys.date-period, @sys.time-period and
+// @sys.date-time parameters describing a range
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// periodKeys are the start and end fields of the different period parameters
+var periodKeys = [][2]string{
+	{"startDate", "endDate"},
+	{"startTime", "endTime"},
+	{"startDateTime", "endDateTime"},
+}
+
+// GetPeriodParam returns a @sys.date-period or @sys.time-period parameter
+func (w *Agent) GetPeriodParam(name string) (Period, error) {
+	fields := w.getField(name).GetStructValue().GetFields()
+	if fields == nil {
+		return Period{}, fmt.Errorf("parameter %v is not a period", name)
+	}
+	for _, keys := range periodKeys {
+		start, end := fields[keys[0]].GetStringValue(), fields[keys[1]].GetStringValue()
+		if start == "" || end == "" {
+			continue
+		}
+		s, err := w.parseTime(start)
+		if err != nil {
+			return Period{}, err
+		}
+		e, err := w.parseTime(end)
+		if err != nil {
+			return Period{}, err
+		}
+		return Period{Start: s, End: e}, nil
+	}
+	return Period{}, fmt.Errorf("parameter %v is not a period", name)
+}
+
+// Contains reports whether t lies within the period, including start and end
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && !t.After(p.End)
+}
+
+// Duration returns the length of the period
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
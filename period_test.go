@@ -0,0 +1,35 @@
+package lambdadialogflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetPeriodParam(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	tests := []struct {
+		param    string
+		timeZone string
+		want     Period
+		ok       bool
+	}{
+		{`{"startDate":"2020-05-01T12:00:00+02:00","endDate":"2020-05-03T12:00:00+02:00"}`, "",
+			Period{time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC), time.Date(2020, 5, 3, 10, 0, 0, 0, time.UTC)}, true},
+		{`{"startTime":"2020-05-01T09:00:00","endTime":"2020-05-01T17:00:00"}`, "Europe/Berlin",
+			Period{time.Date(2020, 5, 1, 9, 0, 0, 0, berlin), time.Date(2020, 5, 1, 17, 0, 0, 0, berlin)}, true},
+		{`{"startDateTime":"2020-05-01","endDateTime":"2020-05-02"}`, "",
+			Period{time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC)}, true},
+		{`{"startDate":"2020-05-01"}`, "", Period{}, false},
+		{`{"startDate":"soon","endDate":"later"}`, "", Period{}, false},
+		{`"next week"`, "", Period{}, false},
+	}
+	for _, test := range tests {
+		w := testRequest(t, `{"responseId":"1","session":"`+testSession+`",`+
+			`"queryResult":{"parameters":{"period":`+test.param+`}},`+
+			`"originalDetectIntentRequest":{"payload":{"timeZone":"`+test.timeZone+`"}}}`)
+		got, err := w.GetPeriodParam("period")
+		if (err == nil) != test.ok || !got.Start.Equal(test.want.Start) || !got.End.Equal(test.want.End) {
+			t.Errorf("GetPeriodParam(%v) = %v, %v, want %v", test.param, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package lambdadialogflow
+
+// Stage is one step of a pipeline. It returns false to stop the pipeline,
+// usually after it responded itself, e.g. because the user lacks an entitlement.
+type Stage func(*Agent) bool
+
+// Pipeline combines stages into a handler running them in order until a stage
+// stops, e.g. Register("order", Pipeline(loadUser, checkEntitlement, Then(order), addUpsell))
+func Pipeline(stages ...Stage) WebhookHandler {
+	return func(w *Agent) {
+		for _, stage := range stages {
+			if !stage(w) {
+				return
+			}
+		}
+	}
+}
+
+// Then turns a handler into a stage which always continues the pipeline
+func Then(handler WebhookHandler) Stage {
+	return func(w *Agent) bool {
+		handler(w)
+		return true
+	}
+}
@@ -0,0 +1,62 @@
+package lambdadialogflow
+
+import (
+	"strings"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// addPlainTextFallback derives a fulfillment text from the rich messages of a
+// response without any text, so integrations ignoring rich messages do not
+// send an empty reply
+func (w *Agent) addPlainTextFallback() {
+	if w.res.FulfillmentText != "" {
+		return
+	}
+	var lines []string
+	for _, msg := range w.res.FulfillmentMessages {
+		if isTextMessage(msg) {
+			return
+		}
+		lines = append(lines, plainText(msg)...)
+	}
+	w.res.FulfillmentText = strings.Join(lines, "\n")
+}
+
+// plainText returns the titles and descriptions of a rich message
+func plainText(msg *df.Intent_Message) []string {
+	var lines []string
+	add := func(parts ...string) {
+		var nonEmpty []string
+		for _, part := range parts {
+			if part != "" {
+				nonEmpty = append(nonEmpty, part)
+			}
+		}
+		if len(nonEmpty) > 0 {
+			lines = append(lines, strings.Join(nonEmpty, ": "))
+		}
+	}
+
+	switch m := msg.GetMessage().(type) {
+	case *df.Intent_Message_Card_:
+		add(m.Card.Title, m.Card.Subtitle)
+	case *df.Intent_Message_BasicCard_:
+		add(m.BasicCard.Title, m.BasicCard.Subtitle)
+		add(m.BasicCard.FormattedText)
+	case *df.Intent_Message_QuickReplies_:
+		add(m.QuickReplies.Title, strings.Join(m.QuickReplies.QuickReplies, ", "))
+	case *df.Intent_Message_Image_:
+		add(m.Image.AccessibilityText)
+	case *df.Intent_Message_ListSelect_:
+		add(m.ListSelect.Title)
+		for _, item := range m.ListSelect.Items {
+			add("• "+item.Title, item.Description)
+		}
+	case *df.Intent_Message_CarouselSelect_:
+		for _, item := range m.CarouselSelect.Items {
+			add("• "+item.Title, item.Description)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,32 @@
+package lambdadialogflow
+
+import df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+
+// AddPlatformPayload appends a custom payload message for one of dialogflow's
+// one-click integrations, wrapped into the key the integration expects,
+// e.g. {"viber": {...}}
+func (w *Agent) AddPlatformPayload(platform df.Intent_Message_Platform, key string, payload map[string]interface{}) {
+	w.AddMessage(&df.Intent_Message{
+		Platform: platform,
+		Message: &df.Intent_Message_Payload{
+			Payload: toValue(map[string]interface{}{key: payload}).GetStructValue(),
+		},
+	})
+}
+
+// AddViberPayload appends a custom payload for the viber integration,
+// e.g. a rich media message or a keyboard
+func (w *Agent) AddViberPayload(payload map[string]interface{}) {
+	w.AddPlatformPayload(df.Intent_Message_VIBER, "viber", payload)
+}
+
+// AddKikPayload appends a custom payload for the kik integration
+func (w *Agent) AddKikPayload(payload map[string]interface{}) {
+	w.AddPlatformPayload(df.Intent_Message_KIK, "kik", payload)
+}
+
+// AddSkypePayload appends a custom payload for the skype integration,
+// e.g. a bot framework attachment
+func (w *Agent) AddSkypePayload(payload map[string]interface{}) {
+	w.AddPlatformPayload(df.Intent_Message_SKYPE, "skype", payload)
+}
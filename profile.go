@@ -0,0 +1,36 @@
+package lambdadialogflow
+
+import (
+	"context"
+	"log"
+)
+
+// ProfileProvider loads the profile of a user, e.g. from a DynamoDB table or
+// a CRM, keyed by the platform the request came from and the user id on it.
+// Unknown users have a nil profile.
+type ProfileProvider interface {
+	Profile(ctx context.Context, platform, userID string) (interface{}, error)
+}
+
+// LoadProfiles returns a middleware loading the profile of the user before
+// the handler runs, see Agent.Profile. Requests without a user id and failed
+// lookups are handled without a profile.
+func LoadProfiles(provider ProfileProvider) Middleware {
+	return func(handler WebhookHandler) WebhookHandler {
+		return func(w *Agent) {
+			if userID := w.UserID(); userID != "" {
+				profile, err := provider.Profile(w.Context(), w.req.GetOriginalDetectIntentRequest().GetSource(), userID)
+				if err != nil {
+					log.Printf("unable to load profile of %v: %v", userID, err)
+				}
+				w.profile = profile
+			}
+			handler(w)
+		}
+	}
+}
+
+// Profile returns the profile of the user loaded by LoadProfiles, or nil
+func (w *Agent) Profile() interface{} {
+	return w.profile
+}
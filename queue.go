@@ -0,0 +1,123 @@
+package lambdadialogflow
+
+import (
+	"html"
+	"strings"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// maxSimpleResponses is the number of simple responses actions on google
+// shows in one turn
+const maxSimpleResponses = 2
+
+// Enqueue queues messages for the response. Unlike Say and AddMessage, which
+// change the response right away, queued messages of middlewares and the
+// handler are merged per platform by Flush, so independent components add to
+// the reply without overwriting each other.
+func (w *Agent) Enqueue(msgs ...*df.Intent_Message) {
+	w.queue = append(w.queue, msgs...)
+}
+
+// EnqueueText queues a text message for all platforms
+func (w *Agent) EnqueueText(text string) {
+	w.Enqueue(&df.Intent_Message{Message: &df.Intent_Message_Text_{Text: &df.Intent_Message_Text{Text: []string{text}}}})
+}
+
+// Flush merges the queued messages of each platform and appends them to the
+// response, leaving messages added to the response directly alone. Consecutive
+// texts and consecutive simple responses are combined, simple responses beyond
+// the two shown by actions on google are joined into the second one, and
+// suggestions and quick replies are combined into the first list of their
+// platform. Messages queued after a flush follow the flushed ones. The queue is flushed after the handler and the
+// transformers ran.
+func (w *Agent) Flush() {
+	if len(w.queue) == 0 {
+		return
+	}
+	w.res.FulfillmentMessages = append(w.res.FulfillmentMessages, mergeMessages(w.queue)...)
+	w.queue = nil
+}
+
+// mergeMessages applies the merging rules of Flush
+func mergeMessages(msgs []*df.Intent_Message) []*df.Intent_Message {
+	var merged []*df.Intent_Message
+	suggestions := make(map[df.Intent_Message_Platform]*df.Intent_Message_Suggestions)
+	quickReplies := make(map[df.Intent_Message_Platform]*df.Intent_Message_QuickReplies)
+
+	for _, msg := range msgs {
+		var last *df.Intent_Message
+		if len(merged) > 0 && merged[len(merged)-1].Platform == msg.Platform {
+			last = merged[len(merged)-1]
+		}
+
+		switch m := msg.GetMessage().(type) {
+		case *df.Intent_Message_Text_:
+			if text := last.GetText(); text != nil {
+				text.Text = append(text.Text, m.Text.Text...)
+				continue
+			}
+		case *df.Intent_Message_SimpleResponses_:
+			if responses := last.GetSimpleResponses(); responses != nil {
+				responses.SimpleResponses = append(responses.SimpleResponses, m.SimpleResponses.SimpleResponses...)
+				continue
+			}
+		case *df.Intent_Message_Suggestions_:
+			if first := suggestions[msg.Platform]; first != nil {
+				first.Suggestions = append(first.Suggestions, m.Suggestions.Suggestions...)
+				continue
+			}
+			suggestions[msg.Platform] = m.Suggestions
+		case *df.Intent_Message_QuickReplies_:
+			if first := quickReplies[msg.Platform]; first != nil {
+				first.QuickReplies = append(first.QuickReplies, m.QuickReplies.QuickReplies...)
+				continue
+			}
+			quickReplies[msg.Platform] = m.QuickReplies
+		}
+		merged = append(merged, msg)
+	}
+
+	for _, msg := range merged {
+		if responses := msg.GetSimpleResponses(); len(responses.GetSimpleResponses()) > maxSimpleResponses {
+			responses.SimpleResponses = append(responses.SimpleResponses[:maxSimpleResponses-1],
+				joinSimpleResponses(responses.SimpleResponses[maxSimpleResponses-1:]))
+		}
+	}
+	return merged
+}
+
+// joinSimpleResponses combines simple responses into one, using SSML if any
+// of them has SSML
+func joinSimpleResponses(responses []*df.Intent_Message_SimpleResponse) *df.Intent_Message_SimpleResponse {
+	var speech, ssml, display []string
+	hasSSML := false
+	for _, r := range responses {
+		speech = append(speech, r.TextToSpeech)
+		display = append(display, r.DisplayText)
+		if r.Ssml != "" {
+			hasSSML = true
+			ssml = append(ssml, strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(r.Ssml), "<speak>"), "</speak>"))
+		} else {
+			ssml = append(ssml, html.EscapeString(r.TextToSpeech))
+		}
+	}
+	joined := &df.Intent_Message_SimpleResponse{DisplayText: joinNonEmpty(display)}
+	if hasSSML {
+		joined.Ssml = "<speak>" + joinNonEmpty(ssml) + "</speak>"
+	} else {
+		joined.TextToSpeech = joinNonEmpty(speech)
+	}
+	return joined
+}
+
+// joinNonEmpty joins the non-empty parts with spaces
+func joinNonEmpty(parts []string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
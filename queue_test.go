@@ -0,0 +1,26 @@
+package lambdadialogflow
+
+import (
+	"testing"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+func TestFlushMergesQueuedMessagesOnly(t *testing.T) {
+	w := &Agent{req: &df.WebhookRequest{}, res: &df.WebhookResponse{}}
+	w.AddText("direct")
+	w.EnqueueText("one")
+	w.EnqueueText("two")
+	w.Flush()
+
+	msgs := w.res.FulfillmentMessages
+	if len(msgs) != 2 {
+		t.Fatalf("messages = %v, want the direct and the merged queued text", msgs)
+	}
+	if got := msgs[0].GetText().GetText(); len(got) != 1 || got[0] != "direct" {
+		t.Errorf("direct text = %v", got)
+	}
+	if got := msgs[1].GetText().GetText(); len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("queued text = %v", got)
+	}
+}
@@ -0,0 +1,20 @@
+package lambdadialogflow
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// Rand returns a random source seeded from the session id. Variants picked
+// with it stay the same throughout a conversation while differing between
+// users, and a turn can be replayed with the same choices when debugging.
+// The source is shared by all calls within a turn and is not safe for
+// concurrent use.
+func (w *Agent) Rand() *rand.Rand {
+	if w.rnd == nil {
+		h := fnv.New64a()
+		h.Write([]byte(w.Session()))
+		w.rnd = rand.New(rand.NewSource(int64(h.Sum64())))
+	}
+	return w.rnd
+}
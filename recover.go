@@ -0,0 +1,20 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// runHandler calls the handler, turning a panic into a failed turn handled as
+// ErrorPanic instead of crashing the lambda invocation
+func (w *Agent) runHandler(handler WebhookHandler) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("panic in handler for action %v: %v\n%s", w.Action(), p, debug.Stack())
+			w.panicked = true
+			w.Fail(fmt.Errorf("panic: %v", p))
+		}
+	}()
+	handler(w)
+}
@@ -0,0 +1,116 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// interactionKey is the session value tracking the last interaction
+const interactionKey = "lambdadialogflow-interaction"
+
+// StaleHook is called on the first turn of a session which was idle for too
+// long. It returns true if it answered the turn, e.g. with "welcome back, want
+// to continue your order?", so the matched handler is skipped.
+type StaleHook func(w *Agent, idle time.Duration) bool
+
+// IdleSession is a session returned by a SessionSweeper
+type IdleSession struct {
+	Session         string
+	UserID          string
+	Source          string
+	Language        string
+	LastInteraction time.Time
+}
+
+// SessionSweeper is implemented by session stores able to list the sessions
+// idle since before a given time, e.g. by a DynamoDB index on the time of the
+// last interaction
+type SessionSweeper interface {
+	IdleSessions(before time.Time) ([]IdleSession, error)
+}
+
+var (
+	trackInteractions bool
+	staleAfter        time.Duration
+	staleHook         StaleHook
+)
+
+// TrackInteractions keeps the time, user and platform of the last interaction
+// in the session store, which needs to be configured
+func TrackInteractions() {
+	trackInteractions = true
+}
+
+// OnStale calls the hook on the next turn of sessions idle for longer than
+// after. It enables TrackInteractions.
+func OnStale(after time.Duration, hook StaleHook) {
+	TrackInteractions()
+	staleAfter = after
+	staleHook = hook
+}
+
+// LastInteraction returns the time of the previous turn of the session, it is
+// zero if unknown
+func (w *Agent) LastInteraction() time.Time {
+	data, _ := w.sessionData[interactionKey].(map[string]interface{})
+	s, _ := data["time"].(string)
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+// staleGate wraps the handler with the stale hook, if the session is stale
+func (w *Agent) staleGate(handler WebhookHandler) WebhookHandler {
+	last := w.LastInteraction()
+	if staleHook == nil || last.IsZero() || time.Since(last) <= staleAfter {
+		return handler
+	}
+	idle := time.Since(last)
+	return func(w *Agent) {
+		if !staleHook(w, idle) {
+			handler(w)
+		}
+	}
+}
+
+// trackInteraction records the current turn as last interaction
+func (w *Agent) trackInteraction() {
+	if !trackInteractions || sessionStore == nil {
+		return
+	}
+	w.SetSessionValue(interactionKey, map[string]interface{}{
+		"time":     time.Now().UTC().Format(time.RFC3339),
+		"userId":   w.UserID(),
+		"source":   w.req.GetOriginalDetectIntentRequest().GetSource(),
+		"language": w.req.GetQueryResult().GetLanguageCode(),
+	})
+}
+
+// Sweep passes all sessions idle for longer than idle to notify, e.g. to send
+// a re-engagement message through Client.Push or the messenger platform. The
+// configured session store has to implement SessionSweeper.
+func Sweep(idle time.Duration, notify func(IdleSession) error) error {
+	sweeper, ok := sessionStore.(SessionSweeper)
+	if !ok {
+		return fmt.Errorf("session store does not support sweeping idle sessions")
+	}
+	sessions, err := sweeper.IdleSessions(time.Now().Add(-idle))
+	if err != nil {
+		return fmt.Errorf("unable to list idle sessions: %v", err)
+	}
+	for _, s := range sessions {
+		if err := notify(s); err != nil {
+			return fmt.Errorf("unable to re-engage session %v: %v", s.Session, err)
+		}
+	}
+	return nil
+}
+
+// StartSweep runs Sweep on every invocation, it is the entry point of a
+// companion lambda triggered by a schedule
+func StartSweep(idle time.Duration, notify func(IdleSession) error) {
+	lambda.Start(func() error {
+		return Sweep(idle, notify)
+	})
+}
@@ -0,0 +1,110 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// NonceStore remembers the response ids of handled requests. Seen records the
+// id until expires and reports whether it was recorded before. Implementations
+// shared by all lambda instances, e.g. a DynamoDB table with a conditional put
+// and a ttl attribute, protect against replays hitting another instance.
+type NonceStore interface {
+	Seen(id string, expires time.Time) (bool, error)
+}
+
+// ReplayGuard rejects requests which were already handled or are too old
+type ReplayGuard struct {
+	// Window is how long response ids are remembered and, if a timestamp
+	// header is configured, how far the request time may be off
+	Window time.Duration
+	// TimestampHeader names a header carrying the unix time of the request,
+	// e.g. added by an api gateway mapping. Leave it empty to skip the check.
+	TimestampHeader string
+	// Nonces defaults to the memory of the lambda instance
+	Nonces NonceStore
+}
+
+var replayGuard *ReplayGuard
+
+// EnableReplayProtection rejects requests whose response id was seen within
+// the window of the guard or whose timestamp lies outside of the window
+func EnableReplayProtection(guard ReplayGuard) {
+	if guard.Nonces == nil {
+		guard.Nonces = &memoryNonces{seen: make(map[string]time.Time)}
+	}
+	replayGuard = &guard
+}
+
+// checkReplay returns an error if the request is a replay
+func (w *Agent) checkReplay(req events.APIGatewayProxyRequest) error {
+	if replayGuard == nil {
+		return nil
+	}
+	now := time.Now()
+	if replayGuard.TimestampHeader != "" {
+		seconds, err := strconv.ParseInt(header(req.Headers, replayGuard.TimestampHeader), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid request timestamp: %v", err)
+		}
+		if d := now.Sub(time.Unix(seconds, 0)); d > replayGuard.Window || d < -replayGuard.Window {
+			return fmt.Errorf("request timestamp outside of window: %v", d)
+		}
+	}
+
+	id := w.req.GetResponseId()
+	if id == "" {
+		return fmt.Errorf("request without response id")
+	}
+	seen, err := replayGuard.Nonces.Seen(id, now.Add(replayGuard.Window))
+	if err != nil {
+		return fmt.Errorf("unable to check response id: %v", err)
+	}
+	if seen {
+		return fmt.Errorf("response id already seen: %v", id)
+	}
+	return nil
+}
+
+// memoryNonces is the NonceStore used if none is configured
+type memoryNonces struct {
+	mutex sync.Mutex
+	seen  map[string]time.Time
+	// expiries holds the recorded ids in the order they expire, which is the
+	// order they were recorded in as the window is fixed
+	expiries []nonceExpiry
+}
+
+// nonceExpiry is the expiry of a recorded id
+type nonceExpiry struct {
+	id      string
+	expires time.Time
+}
+
+// Seen records the id, dropping expired ids on the way
+func (m *memoryNonces) Seen(id string, expires time.Time) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	expired := 0
+	for _, e := range m.expiries {
+		if !now.After(e.expires) {
+			break
+		}
+		// the id may have been recorded again with a later expiry
+		if m.seen[e.id].Equal(e.expires) {
+			delete(m.seen, e.id)
+		}
+		expired++
+	}
+	m.expiries = m.expiries[expired:]
+
+	_, ok := m.seen[id]
+	m.seen[id] = expires
+	m.expiries = append(m.expiries, nonceExpiry{id: id, expires: expires})
+	return ok, nil
+}
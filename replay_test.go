@@ -0,0 +1,61 @@
+package lambdadialogflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestReplayProtection(t *testing.T) {
+	EnableReplayProtection(ReplayGuard{Window: time.Minute})
+	SetDryRun(DryRunOnHeader)
+	defer func() {
+		replayGuard = nil
+		SetDryRun(DryRunOff)
+	}()
+
+	r := NewRouter()
+	r.Register("greet", func(w *Agent) { w.Say("hello") })
+	request := func(id string, headers map[string]string) int {
+		resp, _ := r.ServeContext(context.Background(), events.APIGatewayProxyRequest{
+			Headers: headers,
+			Body:    `{"responseId":"` + id + `","session":"projects/p/agent/sessions/s","queryResult":{"action":"greet"}}`,
+		})
+		return resp.StatusCode
+	}
+
+	if status := request("1", nil); status != 200 {
+		t.Errorf("first request: status %v", status)
+	}
+	if status := request("1", nil); status != 403 {
+		t.Errorf("replayed request: status %v, want 403", status)
+	}
+	if status := request("1", map[string]string{DryRunHeader: "true"}); status != 403 {
+		t.Errorf("replayed dry run: status %v, want 403", status)
+	}
+	if status := request("", nil); status != 403 {
+		t.Errorf("request without response id: status %v, want 403", status)
+	}
+}
+
+func TestMemoryNoncesExpire(t *testing.T) {
+	m := &memoryNonces{seen: make(map[string]time.Time)}
+	past := time.Now().Add(-time.Second)
+	if seen, _ := m.Seen("a", past); seen {
+		t.Error("new id reported as seen")
+	}
+	if seen, _ := m.Seen("b", time.Now().Add(time.Minute)); seen {
+		t.Error("new id reported as seen")
+	}
+	if seen, _ := m.Seen("a", time.Now().Add(time.Minute)); seen {
+		t.Error("expired id reported as seen")
+	}
+	if seen, _ := m.Seen("b", time.Now().Add(time.Minute)); !seen {
+		t.Error("recorded id not reported as seen")
+	}
+	if len(m.seen) != 2 {
+		t.Errorf("%d ids recorded, want 2", len(m.seen))
+	}
+}
@@ -0,0 +1,33 @@
+package lambdadialogflow
+
+// replyContext marks a session expecting a reply for a temporary handler
+const replyContext = "lambdadialogflow-reply"
+
+// RegisterReply registers a handler that only runs for replies expected with
+// ExpectReply. It is kept apart from the action handlers, so dialogflow
+// actions never match it.
+func RegisterReply(name string, handler WebhookHandler) {
+	defaultRouter.RegisterReply(name, handler)
+}
+
+// RegisterReply registers a reply handler with the router, see RegisterReply
+func (r *Router) RegisterReply(name string, handler WebhookHandler) {
+	r.replyHandlers[name] = handler
+}
+
+// ExpectReply routes the next turn of the session to the reply handler with
+// the given name, whatever intent dialogflow matches, e.g. to capture a free
+// form answer. The name is kept in a context, as the next turn may be served
+// by another lambda instance.
+func (w *Agent) ExpectReply(name string) {
+	w.OutputContext(replyContext).SetLifespan(1).SetParam("handler", name)
+}
+
+// expectedReply returns the reply handler expected by the previous turn
+func expectedReply(w *Agent) WebhookHandler {
+	ctx := w.inputContext(replyContext)
+	if ctx == nil {
+		return nil
+	}
+	return w.router.replyHandlers[ctx.GetParameters().GetFields()["handler"].GetStringValue()]
+}
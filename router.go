@@ -0,0 +1,271 @@
+package lambdadialogflow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Router dispatches webhook requests to the handlers registered for their
+// action or intent. Independent routers allow serving several dialogflow
+// agents from one binary, the package level functions use a default router.
+type Router struct {
+	handlers         map[string]WebhookHandler
+	intentHandlers   map[string]WebhookHandler
+	requiredContexts map[string]string
+	replyHandlers    map[string]WebhookHandler
+	notFound         WebhookHandler
+	middlewares      []Middleware
+	transformers     []ResponseTransformer
+}
+
+// Middleware wraps a handler with cross-cutting behavior like logging or
+// auth checks, e.g. RequireAuth
+type Middleware func(WebhookHandler) WebhookHandler
+
+// NewRouter creates a router without any handlers
+func NewRouter() *Router {
+	return &Router{
+		handlers:         make(map[string]WebhookHandler),
+		intentHandlers:   make(map[string]WebhookHandler),
+		requiredContexts: make(map[string]string),
+		replyHandlers:    make(map[string]WebhookHandler),
+	}
+}
+
+// Register a new webhook handler for an action
+func (r *Router) Register(action string, handler WebhookHandler) {
+	r.handlers[action] = handler
+}
+
+// RegisterIntent registers a webhook handler for an intent by its display
+// name. It is used for requests without a handler for their action.
+func (r *Router) RegisterIntent(displayName string, handler WebhookHandler) {
+	r.intentHandlers[displayName] = handler
+}
+
+// NotFound sets the handler for requests without a registered handler or
+// whose follow-up context is not active. Without it these requests fail
+// according to the error policy.
+func (r *Router) NotFound(handler WebhookHandler) {
+	r.notFound = handler
+}
+
+// RegisterDefault is an alias of NotFound, see RegisterDefault
+func (r *Router) RegisterDefault(handler WebhookHandler) {
+	r.NotFound(handler)
+}
+
+// Use adds middlewares running around every matched handler. The first
+// middleware added is the outermost one.
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// ResponseTransformer rewrites the response after the handler, e.g. to append
+// a legal disclaimer. It reads and modifies the response through the agent.
+type ResponseTransformer func(*Agent)
+
+// Transform adds transformers running in order after every handler, before
+// the response is marshaled
+func (r *Router) Transform(transformers ...ResponseTransformer) {
+	r.transformers = append(r.transformers, transformers...)
+}
+
+// wrap applies the middlewares of the router to the handler
+func (r *Router) wrap(handler WebhookHandler) WebhookHandler {
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+	return handler
+}
+
+// handler returns the handler registered for an action
+func (r *Router) handler(action string) WebhookHandler {
+	return r.handlers[action]
+}
+
+// lookup returns the handler registered for the action of the request,
+// falling back to the handler registered for the intent
+func (r *Router) lookup(w *Agent) WebhookHandler {
+	if handler := r.handlers[w.Action()]; handler != nil {
+		return handler
+	}
+	return r.intentHandlers[w.req.GetQueryResult().GetIntent().GetDisplayName()]
+}
+
+// route finds the handler for the request. It reports whether the handler is
+// the one registered for the action, rather than a pending flow like the
+// consent gate or a confirmation, or the not found handler, taking the turn.
+func (r *Router) route(w *Agent) (WebhookHandler, bool, error) {
+	if gate := consentFlow.gate(w); gate != nil {
+		return gate, false, nil
+	}
+	if handler := expectedReply(w); handler != nil {
+		return handler, false, nil
+	}
+	if handler := confirmation(w); handler != nil {
+		return handler, false, nil
+	}
+	if handler := dateRange(w); handler != nil {
+		return handler, false, nil
+	}
+	if handler := resume(w, formContext); handler != nil {
+		return handler, false, nil
+	}
+	if handler := suggestion(w); handler != nil {
+		return handler, false, nil
+	}
+
+	webhookHandler := r.lookup(w)
+	if webhookHandler == nil {
+		if r.notFound != nil {
+			return r.notFound, false, nil
+		}
+		return nil, false, fmt.Errorf("no handler defined for action: %v", w.Action())
+	}
+
+	if ctx, ok := r.requiredContexts[w.Action()]; ok && w.inputContext(ctx) == nil {
+		if r.notFound != nil {
+			return r.notFound, false, nil
+		}
+		return nil, false, fmt.Errorf("context %v required by action %v is not active", ctx, w.Action())
+	}
+	return webhookHandler, r.handlers[w.Action()] != nil, nil
+}
+
+// resume returns the handler of the action kept in the "action" parameter of
+// a multi-turn flow's context, or nil if the context is not active or the user
+// moved on to another intent with a registered handler
+func resume(w *Agent, name string) WebhookHandler {
+	ctx := w.inputContext(name)
+	if ctx == nil {
+		return nil
+	}
+	if w.router.lookup(w) != nil && !w.req.GetQueryResult().GetIntent().GetIsFallback() {
+		return nil
+	}
+	return w.router.handler(ctx.GetParameters().GetFields()["action"].GetStringValue())
+}
+
+// Serve handles the dialogflow request coming in via the lambda api gateway
+func (r *Router) Serve(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return r.ServeContext(context.Background(), req)
+}
+
+// ServeContext handles the dialogflow request, passing ctx on to the handlers
+func (r *Router) ServeContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return r.serve(ctx, req, serveOptions{dryRun: dryRunRequested(req.Headers)})
+}
+
+// serveOptions are the variations of serving a request used internally
+type serveOptions struct {
+	// dryRun serves the request without side effects
+	dryRun bool
+	// recorded requests were handled before, e.g. replayed by DiffRouters,
+	// and skip the replay protection
+	recorded bool
+}
+
+// serve handles the dialogflow request
+func (r *Router) serve(ctx context.Context, req events.APIGatewayProxyRequest, opts serveOptions) (resp events.APIGatewayProxyResponse, err error) {
+	start := time.Now()
+	fields, err := decodeRawRequest([]byte(req.Body))
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 400}, err
+	}
+	if version := fields.version(); !supportedVersions[version] {
+		return unsupportedRequest(version)
+	}
+	w, err := parse([]byte(req.Body), fields)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 400}, err
+	}
+	w.router = r
+	w.ctx = ctx
+	w.dryRun = opts.dryRun
+	defer func() {
+		w.recordRequest(start, resp.StatusCode, err)
+	}()
+
+	if !opts.recorded {
+		if err := w.checkReplay(req); err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: 403}, err
+		}
+	}
+
+	if err := w.loadSession(); err != nil {
+		return handleError(req, w, ErrorInternal, 500, err)
+	}
+	w.expireState()
+
+	webhookHandler, plain, err := r.route(w)
+	if err != nil {
+		return handleError(req, w, ErrorNoHandler, 404, err)
+	}
+
+	// only responses of the action's own handler are cached, the middlewares
+	// run for cached responses too
+	var cacheKey string
+	if plain {
+		cacheKey = w.cacheKey(req)
+		webhookHandler = cached(cacheKey, webhookHandler)
+	}
+
+	release, ok := acquireSlot()
+	if !ok {
+		return respond(req, busyResponse())
+	}
+	defer release()
+	w.runHandler(r.wrap(w.staleGate(webhookHandler)))
+	if w.panicked {
+		return handleError(req, w, ErrorPanic, 500, w.handlerErr)
+	}
+	if err := w.deadlineExceeded(); err != nil {
+		return handleError(req, w, ErrorTimeout, 504, err)
+	}
+	if w.handlerErr != nil && w.failure == ErrorNoHandler {
+		return handleError(req, w, ErrorNoHandler, 404, w.handlerErr)
+	}
+	if w.handlerErr != nil {
+		return handleError(req, w, ErrorHandlerFailed, handlerErrorStatus, w.handlerErr)
+	}
+	if w.cacheHit {
+		w.reportUsage(w.res)
+		return respond(req, w.res)
+	}
+	for _, transform := range r.transformers {
+		transform(w)
+	}
+	w.Flush()
+	w.addPlainTextFallback()
+	w.applyTextLimits()
+
+	w.trackInteraction()
+	w.stampState()
+	if err := w.saveSession(); err != nil {
+		return handleError(req, w, ErrorInternal, 500, err)
+	}
+	w.cacheResponse(cacheKey)
+	if err := w.logTurn(); err != nil {
+		log.Printf("unable to log turn: %v", err)
+	}
+	w.reportUsage(w.res)
+	w.exportEvents()
+	w.reportSideEffects()
+
+	resp, err = respond(req, w.res)
+	if err != nil {
+		return handleError(req, w, ErrorInternal, 500, err)
+	}
+	return resp, nil
+}
+
+// Start listening on requests
+func (r *Router) Start() {
+	lambda.Start(r.ServeContext)
+}
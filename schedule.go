@@ -0,0 +1,78 @@
+package lambdadialogflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// ScheduledAction is the event passed to the scheduled entry point
+type ScheduledAction struct {
+	Action   string                 `json:"action"`
+	Session  string                 `json:"session"`
+	UserID   string                 `json:"userId,omitempty"`
+	Source   string                 `json:"source,omitempty"`
+	Language string                 `json:"languageCode,omitempty"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+}
+
+// Scheduler creates one-off schedules. The aws package provides a scheduler
+// using EventBridge Scheduler with the lambda running StartScheduled as target.
+type Scheduler interface {
+	Schedule(at time.Time, action ScheduledAction) error
+}
+
+// ScheduledHandler handles an action which was scheduled by a webhook handler
+type ScheduledHandler func(ScheduledAction) error
+
+var (
+	scheduler           Scheduler
+	scheduledHandlerMap = make(map[string]ScheduledHandler)
+)
+
+// SetScheduler sets the scheduler used by Agent.ScheduleAction
+func SetScheduler(s Scheduler) {
+	scheduler = s
+}
+
+// RegisterScheduled registers a handler for a scheduled action
+func RegisterScheduled(action string, handler ScheduledHandler) {
+	scheduledHandlerMap[action] = handler
+}
+
+// ScheduleAction schedules an action for the session of the current request,
+// e.g. to deliver a reminder in two hours
+func (w *Agent) ScheduleAction(at time.Time, action string, params map[string]interface{}) error {
+	if scheduler == nil {
+		return errors.New("no scheduler configured")
+	}
+	scheduled := ScheduledAction{
+		Action:   action,
+		Session:  w.Session(),
+		UserID:   w.UserID(),
+		Source:   w.req.GetOriginalDetectIntentRequest().GetSource(),
+		Language: w.req.GetQueryResult().GetLanguageCode(),
+		Params:   params,
+	}
+	if w.capture("schedule", map[string]interface{}{"at": at, "action": scheduled}) {
+		return nil
+	}
+	return scheduler.Schedule(at, scheduled)
+}
+
+// HandleScheduled handles a scheduled action invoked by the scheduler
+func HandleScheduled(action ScheduledAction) error {
+	handler := scheduledHandlerMap[action.Action]
+	if handler == nil {
+		return fmt.Errorf("no handler defined for scheduled action: %v", action.Action)
+	}
+	return handler(action)
+}
+
+// StartScheduled listens on scheduled actions, it is the entry point of the
+// companion lambda targeted by the schedules
+func StartScheduled() {
+	lambda.Start(HandleScheduled)
+}
@@ -0,0 +1,89 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RequestVersion is the dialogflow API a webhook request was sent by
+type RequestVersion string
+
+// Request versions detected by DetectVersion
+const (
+	VersionV2      RequestVersion = "v2"
+	VersionV2Beta1 RequestVersion = "v2beta1"
+	VersionCX      RequestVersion = "cx"
+	VersionUnknown RequestVersion = "unknown"
+)
+
+// supportedVersions are the request versions the package can handle
+var supportedVersions = map[RequestVersion]bool{VersionV2: true, VersionV2Beta1: true}
+
+// rawRequest is a webhook request decoded into its top level fields. It is
+// decoded once per request for the version detection and the v2beta1 fields.
+type rawRequest map[string]json.RawMessage
+
+// decodeRawRequest decodes the top level fields of a webhook request
+func decodeRawRequest(body []byte) (rawRequest, error) {
+	var fields rawRequest
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("unable to decode webhook request: %v", err)
+	}
+	return fields, nil
+}
+
+// DetectVersion detects the dialogflow API version by the shape of a webhook
+// request. Dialogflow CX sends session and page info instead of a query result.
+func DetectVersion(body []byte) RequestVersion {
+	fields, err := decodeRawRequest(body)
+	if err != nil {
+		return VersionUnknown
+	}
+	return fields.version()
+}
+
+// version detects the dialogflow API version of the request, see DetectVersion
+func (fields rawRequest) version() RequestVersion {
+	for _, key := range []string{"detectIntentResponseId", "fulfillmentInfo", "sessionInfo", "pageInfo"} {
+		if _, ok := fields[key]; ok {
+			return VersionCX
+		}
+	}
+	queryResult, ok := fields["queryResult"]
+	if !ok {
+		return VersionUnknown
+	}
+	if _, ok := fields["alternativeQueryResults"]; ok {
+		return VersionV2Beta1
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(queryResult, &result); err == nil {
+		if _, ok := result["knowledgeAnswers"]; ok {
+			return VersionV2Beta1
+		}
+	}
+	return VersionV2
+}
+
+// unsupportedRequest answers requests of an unsupported version with a
+// structured error, e.g. when the fulfillment url of a CX agent points here
+func unsupportedRequest(version RequestVersion) (events.APIGatewayProxyResponse, error) {
+	err := fmt.Errorf("unsupported webhook request version: %v, expected dialogflow ES v2", version)
+	body, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]string{
+			"code":    "unsupported_request",
+			"version": string(version),
+			"message": err.Error(),
+		},
+	})
+	log.Printf("%s", body)
+	writeMetrics("", "", "", metric{Name: "UnsupportedRequests", Unit: "Count", value: 1})
+	return events.APIGatewayProxyResponse{
+		StatusCode: 400,
+		Body:       string(body),
+		Headers:    map[string]string{"Content-Type": "application/json"},
+	}, err
+}
@@ -0,0 +1,19 @@
+package lambdadialogflow
+
+// Sentiment is the sentiment of the query as analyzed by dialogflow
+type Sentiment struct {
+	// Score ranges from -1 (negative) to 1 (positive)
+	Score float32
+	// Magnitude is the strength of the emotion regardless of the score
+	Magnitude float32
+}
+
+// Sentiment returns the sentiment of the query. It is only available if
+// sentiment analysis is enabled in the settings of the agent.
+func (w *Agent) Sentiment() (Sentiment, bool) {
+	s := w.req.GetQueryResult().GetSentimentAnalysisResult().GetQueryTextSentiment()
+	if s == nil {
+		return Sentiment{}, false
+	}
+	return Sentiment{Score: s.GetScore(), Magnitude: s.GetMagnitude()}, true
+}
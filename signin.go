@@ -0,0 +1,66 @@
+package lambdadialogflow
+
+// signedInKey is the session store flag marking users who signed in by other means
+const signedInKey = "signedIn"
+
+var signInPrompt = "Please link your account to continue."
+
+// SetSignInPrompt sets the text asking users to link their account. On actions
+// on google it is passed as context to the sign-in helper.
+func SetSignInPrompt(text string) {
+	signInPrompt = text
+}
+
+// AccessToken returns the token of the linked account sent by actions on google
+func (w *Agent) AccessToken() string {
+	return w.payloadString("user", "accessToken")
+}
+
+// SignedIn reports whether the user linked an account or was flagged as signed in
+func (w *Agent) SignedIn() bool {
+	if w.AccessToken() != "" || w.payloadString("user", "idToken") != "" {
+		return true
+	}
+	signedIn, _ := w.SessionValue(signedInKey).(bool)
+	return signedIn
+}
+
+// SetSignedIn flags the user of the session as signed in, e.g. after a login
+// flow outside of the platform's account linking. It requires a session store.
+func (w *Agent) SetSignedIn(signedIn bool) {
+	w.SetSessionValue(signedInKey, signedIn)
+}
+
+// AskForSignIn asks the user to link their account, using the sign-in helper
+// on actions on google and a text message on all other platforms
+func (w *Agent) AskForSignIn(text string) {
+	w.Say(text)
+	if !w.IsAssistant() {
+		return
+	}
+	w.AddPayloadStruct("google", map[string]interface{}{
+		"expectUserResponse": true,
+		"systemIntent": map[string]interface{}{
+			"intent": "actions.intent.SIGN_IN",
+			"data": map[string]interface{}{
+				"@type":      "type.googleapis.com/google.actions.v2.SignInValueSpec",
+				"optContext": text,
+			},
+		},
+	})
+}
+
+// RequireAuth wraps a handler so it only runs for signed in users, all other
+// users are asked to link their account
+func RequireAuth(handler WebhookHandler) WebhookHandler {
+	return func(w *Agent) {
+		if !w.SignedIn() {
+			// the prompt must not be cached for users who are signed in
+			w.NoCache()
+			w.AskForSignIn(signInPrompt)
+			return
+		}
+		w.authRequired = true
+		handler(w)
+	}
+}
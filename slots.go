@@ -0,0 +1,97 @@
+package lambdadialogflow
+
+import "strings"
+
+// dialogParamsMarker is part of the context dialogflow sets while prompting
+// for a required parameter: <intent>_dialog_params_<parameter>
+const dialogParamsMarker = "_dialog_params_"
+
+// SlotFilling reports whether dialogflow is still collecting required parameters
+func (w *Agent) SlotFilling() bool {
+	return !w.req.GetQueryResult().GetAllRequiredParamsPresent()
+}
+
+// PromptedParam returns the parameter dialogflow is currently prompting for.
+// Dialogflow lowercases context names, so the name is returned in lowercase.
+func (w *Agent) PromptedParam() string {
+	if !w.SlotFilling() {
+		return ""
+	}
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		name := shortContextName(ctx.Name)
+		if i := strings.Index(name, dialogParamsMarker); i >= 0 {
+			return name[i+len(dialogParamsMarker):]
+		}
+	}
+	return ""
+}
+
+// PromptFor replaces the static prompt of a required parameter for this turn,
+// e.g. to include the currently available time slots. It only takes effect
+// while dialogflow is prompting for that parameter, the slot filling contexts
+// are left untouched so dialogflow keeps collecting the remaining parameters.
+func (w *Agent) PromptFor(param, text string) bool {
+	if !strings.EqualFold(w.PromptedParam(), param) {
+		return false
+	}
+	w.Say(text)
+	return true
+}
+
+// dialogContextSuffix ends the context dialogflow keeps while slot filling
+const dialogContextSuffix = "_dialog_context"
+
+// paramPrompts are the re-prompts of RequireParams by parameter name
+var paramPrompts = make(map[string]string)
+
+// AllParamsPresent reports whether all required parameters of the intent are filled
+func (w *Agent) AllParamsPresent() bool {
+	return w.req.GetQueryResult().GetAllRequiredParamsPresent()
+}
+
+// SetParamPrompt sets the text RequireParams asks for a missing parameter with
+func SetParamPrompt(param, text string) {
+	paramPrompts[param] = text
+}
+
+// RequireParams reports whether all given parameters are filled. Otherwise it
+// asks for the missing parameter dialogflow is prompting for, or the first
+// missing one, with the text set by SetParamPrompt and keeps the slot filling
+// contexts active for the next turn. Without a prompt text the static prompt
+// of the intent is used.
+func (w *Agent) RequireParams(names ...string) bool {
+	var missing []string
+	for _, name := range names {
+		if !hasValue(w.getField(name)) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return true
+	}
+
+	ask := missing[0]
+	for _, name := range missing {
+		if strings.EqualFold(name, w.PromptedParam()) {
+			ask = name
+		}
+	}
+	if text, ok := paramPrompts[ask]; ok {
+		w.Say(text)
+	}
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		name := shortContextName(ctx.Name)
+		if !strings.HasSuffix(name, dialogContextSuffix) && !strings.Contains(name, dialogParamsMarker) {
+			continue
+		}
+		lifespan := ctx.LifespanCount
+		if lifespan < 2 {
+			lifespan = 2
+		}
+		out := w.OutputContext(ctx.Name).SetLifespan(lifespan)
+		for key, value := range ctx.GetParameters().GetFields() {
+			out.SetParam(key, value)
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package lambdadialogflow
+
+import "strings"
+
+// Snapshot is the serializable state of a conversation. It can be restored in
+// another session, e.g. to hand a conversation over from web chat to phone.
+type Snapshot struct {
+	Session  string            `json:"session"`
+	Contexts []SnapshotContext `json:"contexts"`
+	// Parameters of the current turn, for inspection only. Dialogflow carries
+	// parameters between turns in the contexts.
+	Parameters  map[string]interface{} `json:"parameters"`
+	SessionData map[string]interface{} `json:"sessionData"`
+}
+
+// SnapshotContext is an active context within a snapshot
+type SnapshotContext struct {
+	Name     string                 `json:"name"`
+	Lifespan int32                  `json:"lifespan"`
+	Params   map[string]interface{} `json:"params"`
+}
+
+// Snapshot returns the state of the conversation after the current turn: the
+// active contexts including the changes of the response and the session data
+func (w *Agent) Snapshot() Snapshot {
+	var names []string
+	contexts := make(map[string]SnapshotContext)
+	add := func(name string, lifespan int32, params map[string]interface{}) {
+		name = strings.ToLower(shortContextName(name))
+		if _, ok := contexts[name]; !ok {
+			names = append(names, name)
+		}
+		contexts[name] = SnapshotContext{Name: name, Lifespan: lifespan, Params: params}
+	}
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		add(ctx.Name, ctx.LifespanCount, fromStruct(ctx.Parameters))
+	}
+	for _, ctx := range w.res.OutputContexts {
+		add(ctx.Name, ctx.LifespanCount, fromStruct(ctx.Parameters))
+	}
+
+	s := Snapshot{
+		Session:     w.Session(),
+		Parameters:  fromStruct(w.req.GetQueryResult().GetParameters()),
+		SessionData: make(map[string]interface{}, len(w.sessionData)),
+	}
+	for _, name := range names {
+		if contexts[name].Lifespan > 0 {
+			s.Contexts = append(s.Contexts, contexts[name])
+		}
+	}
+	for key, value := range w.sessionData {
+		s.SessionData[key] = value
+	}
+	return s
+}
+
+// RestoreSnapshot replaces the state of the current session with the
+// snapshot. Active contexts missing from the snapshot are cleared.
+func (w *Agent) RestoreSnapshot(s Snapshot) error {
+	specs := make([]ContextSpec, 0, len(s.Contexts))
+	restored := make(map[string]bool, len(s.Contexts))
+	for _, ctx := range s.Contexts {
+		specs = append(specs, ContextSpec{Name: ctx.Name, Lifespan: ctx.Lifespan, Params: ctx.Params})
+		restored[strings.ToLower(ctx.Name)] = true
+	}
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		name := strings.ToLower(shortContextName(ctx.Name))
+		if !restored[name] {
+			specs = append(specs, ContextSpec{Name: name})
+		}
+	}
+	if err := w.SetContexts(specs); err != nil {
+		return err
+	}
+
+	w.sessionData = make(map[string]interface{}, len(s.SessionData))
+	for key, value := range s.SessionData {
+		w.sessionData[key] = value
+	}
+	w.sessionDirty = true
+	return nil
+}
@@ -0,0 +1,44 @@
+package lambdadialogflow
+
+// Source is the integration a request came from
+type Source string
+
+// Sources returned by Agent.Source
+const (
+	SourceGoogleAssistant   Source = "google"
+	SourceFacebookMessenger Source = "facebook"
+	SourceSlack             Source = "slack"
+	SourceTelegram          Source = "telegram"
+	SourceViber             Source = "viber"
+	SourceDialogflowConsole Source = "console"
+	SourcePhoneGateway      Source = "telephony"
+	SourceUnknown           Source = "unknown"
+)
+
+// sources maps the source of the original request onto the integration
+var sources = map[string]Source{
+	"google":             SourceGoogleAssistant,
+	"facebook":           SourceFacebookMessenger,
+	"slack":              SourceSlack,
+	"slack_testbot":      SourceSlack,
+	"telegram":           SourceTelegram,
+	"viber":              SourceViber,
+	"":                   SourceDialogflowConsole,
+	"DIALOGFLOW_CONSOLE": SourceDialogflowConsole,
+	telephonySource:      SourcePhoneGateway,
+}
+
+// Source returns the integration the request came from. Requests without a
+// source are sent by the simulator of the dialogflow console or by direct
+// detectIntent calls.
+func (w *Agent) Source() Source {
+	if source, ok := sources[w.req.GetOriginalDetectIntentRequest().GetSource()]; ok {
+		return source
+	}
+	return SourceUnknown
+}
+
+// IsAssistant reports whether the request came from actions on google
+func (w *Agent) IsAssistant() bool {
+	return w.Source() == SourceGoogleAssistant
+}
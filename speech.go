@@ -0,0 +1,19 @@
+package lambdadialogflow
+
+// speechContextsKey is the payload key holding speech contexts for the next turn
+const speechContextsKey = "speechContexts"
+
+// AddSpeechContext boosts the recognition of the given phrases in the next
+// turn. Dialogflow cannot change speech recognition from a webhook, so the
+// speech contexts are added to the response payload, where voice clients read
+// them and pass them on as InputAudioConfig.speechContexts of the next
+// detectIntent request. Phrases may use class tokens like
+// "$OOV_CLASS_DIGIT_SEQUENCE" when expecting a code.
+func (w *Agent) AddSpeechContext(boost float32, phrases ...string) {
+	contexts, _ := fromStruct(w.res.GetPayload())[speechContextsKey].([]interface{})
+	ctx := map[string]interface{}{"phrases": phrases}
+	if boost != 0 {
+		ctx["boost"] = boost
+	}
+	w.AddPayloadList(speechContextsKey, append(contexts, ctx))
+}
@@ -0,0 +1,75 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ssmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// SaySSML speaks the SSML on actions on google. The display text and the
+// fulfillment text for other platforms are the SSML without its tags.
+func (w *Agent) SaySSML(ssml string) {
+	text := strings.Join(strings.Fields(html.UnescapeString(ssmlTag.ReplaceAllString(ssml, " "))), " ")
+	w.SayWithSSML(text, ssml)
+}
+
+// SSML builds speech output, e.g.
+// NewSSML().Text("Your code is").SayAs("characters", "A7").Pause(time.Second).String()
+type SSML struct {
+	b strings.Builder
+}
+
+// NewSSML creates an empty SSML builder
+func NewSSML() *SSML {
+	return &SSML{}
+}
+
+// Text adds text to be spoken
+func (s *SSML) Text(text string) *SSML {
+	s.space()
+	s.b.WriteString(html.EscapeString(text))
+	return s
+}
+
+// Pause adds a break of the given duration
+func (s *SSML) Pause(d time.Duration) *SSML {
+	fmt.Fprintf(&s.b, `<break time="%dms"/>`, d.Milliseconds())
+	return s
+}
+
+// Emphasis speaks the text with an emphasis level of strong, moderate or reduced
+func (s *SSML) Emphasis(level, text string) *SSML {
+	s.space()
+	fmt.Fprintf(&s.b, `<emphasis level="%s">%s</emphasis>`, html.EscapeString(level), html.EscapeString(text))
+	return s
+}
+
+// SayAs speaks the text as interpreted, e.g. "cardinal", "characters" or "date"
+func (s *SSML) SayAs(interpretAs, text string) *SSML {
+	s.space()
+	fmt.Fprintf(&s.b, `<say-as interpret-as="%s">%s</say-as>`, html.EscapeString(interpretAs), html.EscapeString(text))
+	return s
+}
+
+// Audio plays the audio file, the fallback text is spoken if it fails to load
+func (s *SSML) Audio(src, fallback string) *SSML {
+	s.space()
+	fmt.Fprintf(&s.b, `<audio src="%s">%s</audio>`, html.EscapeString(src), html.EscapeString(fallback))
+	return s
+}
+
+// String returns the SSML document
+func (s *SSML) String() string {
+	return "<speak>" + s.b.String() + "</speak>"
+}
+
+// space separates the next part from the previous text
+func (s *SSML) space() {
+	if s.b.Len() > 0 && !strings.HasSuffix(s.b.String(), "/>") {
+		s.b.WriteString(" ")
+	}
+}
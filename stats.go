@@ -0,0 +1,128 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statsPayloadKey is the payload field carrying the stats of StatsHandler
+const statsPayloadKey = "stats"
+
+// Stats are the requests served by this process since its cold start
+type Stats struct {
+	Uptime    time.Duration  `json:"uptime"`
+	Requests  int            `json:"requests"`
+	Errors    int            `json:"errors"`
+	ErrorRate float64        `json:"errorRate"`
+	Intents   map[string]int `json:"intents"`
+	Version   string         `json:"version"`
+}
+
+var (
+	statsMu       sync.Mutex
+	statsStart    = time.Now()
+	statsRequests int
+	statsErrors   int
+	statsIntents  = make(map[string]int)
+	buildVersion  string
+)
+
+// SetBuildVersion sets the version reported by StatsHandler, e.g. set from a
+// linker flag. It defaults to the vcs revision the binary was built from.
+func SetBuildVersion(version string) {
+	buildVersion = version
+}
+
+// recordStats counts a request for the stats
+func recordStats(intent string, failed bool) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	statsRequests++
+	if failed {
+		statsErrors++
+	}
+	statsIntents[intent]++
+}
+
+// CurrentStats returns the stats of this process
+func CurrentStats() Stats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	s := Stats{
+		Uptime:   time.Since(statsStart),
+		Requests: statsRequests,
+		Errors:   statsErrors,
+		Intents:  make(map[string]int, len(statsIntents)),
+		Version:  version(),
+	}
+	if s.Requests > 0 {
+		s.ErrorRate = float64(s.Errors) / float64(s.Requests)
+	}
+	for intent, count := range statsIntents {
+		s.Intents[intent] = count
+	}
+	return s
+}
+
+// version returns the build version or the vcs revision of the binary
+func version() string {
+	if buildVersion != "" {
+		return buildVersion
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return info.Main.Version
+}
+
+// StatsHandler returns a handler replying with the live stats of the process,
+// so operators can ask the bot itself during incidents. Requests not allowed
+// by authorized fail like requests without a handler. The stats are sent as
+// text and as "stats" field of the payload.
+func StatsHandler(authorized func(*Agent) bool) WebhookHandler {
+	return func(w *Agent) {
+		if authorized == nil || !authorized(w) {
+			w.failNoHandler(fmt.Errorf("not authorized for stats: %v", w.UserID()))
+			return
+		}
+		s := CurrentStats()
+
+		intents := make([]string, 0, len(s.Intents))
+		for intent := range s.Intents {
+			intents = append(intents, intent)
+		}
+		sort.Slice(intents, func(i, j int) bool {
+			if s.Intents[intents[i]] != s.Intents[intents[j]] {
+				return s.Intents[intents[i]] > s.Intents[intents[j]]
+			}
+			return intents[i] < intents[j]
+		})
+		if len(intents) > 5 {
+			intents = intents[:5]
+		}
+		for i, intent := range intents {
+			intents[i] = fmt.Sprintf("%v (%d)", intent, s.Intents[intent])
+		}
+
+		w.Say(fmt.Sprintf("Version %v, up %v. %d requests, %.1f%% errors. Top intents: %v.",
+			s.Version, s.Uptime.Round(time.Second), s.Requests, s.ErrorRate*100, strings.Join(intents, ", ")))
+		w.addPayloadValue(statsPayloadKey, toValue(map[string]interface{}{
+			"uptimeSeconds": int(s.Uptime.Seconds()),
+			"requests":      s.Requests,
+			"errors":        s.Errors,
+			"errorRate":     s.ErrorRate,
+			"intents":       s.Intents,
+			"version":       s.Version,
+		}))
+	}
+}
@@ -0,0 +1,28 @@
+package lambdadialogflow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestStatsHandlerUnauthorized(t *testing.T) {
+	queue := &recordingQueue{}
+	SetDeadLetterQueue(queue)
+	defer SetDeadLetterQueue(nil)
+
+	r := NewRouter()
+	r.Register("stats", StatsHandler(func(w *Agent) bool { return w.UserID() == "operator" }))
+	body := `{"responseId":"1","session":"` + testSession + `","queryResult":{"action":"stats"}}`
+	resp, err := r.Serve(events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: body})
+	if err == nil || resp.StatusCode != 404 {
+		t.Errorf("status %v, error %v", resp.StatusCode, err)
+	}
+	if len(queue.letters) != 0 {
+		t.Errorf("dead letters = %+v", queue.letters)
+	}
+	if strings.Contains(resp.Body, "Version") {
+		t.Errorf("stats sent to unauthorized user: %v", resp.Body)
+	}
+}
@@ -0,0 +1,135 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// SessionStore persists data of a conversation between turns. Implementations
+// keep one document per session, e.g. a Firestore document in the project of
+// the dialogflow agent or a DynamoDB item keyed by the session.
+type SessionStore interface {
+	Load(session string) (map[string]interface{}, error)
+	Save(session string, data map[string]interface{}) error
+}
+
+var sessionStore SessionStore
+
+// SetSessionStore sets the store used for session data
+func SetSessionStore(s SessionStore) {
+	sessionStore = s
+}
+
+// loadSession loads the session data before the handler runs
+func (w *Agent) loadSession() error {
+	w.sessionData = make(map[string]interface{})
+	if sessionStore == nil {
+		return nil
+	}
+	data, err := sessionStore.Load(w.Session())
+	if err != nil {
+		return fmt.Errorf("unable to load session: %v", err)
+	}
+	if data != nil {
+		w.sessionData = data
+	}
+	return nil
+}
+
+// saveSession saves the session data after the handler changed it
+func (w *Agent) saveSession() error {
+	if sessionStore == nil || !w.sessionDirty {
+		return nil
+	}
+	// only the keys are reported, the values may be personal data the caller
+	// of the dry run must not see
+	if w.dryRun {
+		keys := make([]string, 0, len(w.sessionData))
+		for key := range w.sessionData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.capture("session", map[string]interface{}{"keys": keys})
+		return nil
+	}
+	if err := sessionStore.Save(w.Session(), w.sessionData); err != nil {
+		return fmt.Errorf("unable to save session: %v", err)
+	}
+	return nil
+}
+
+// SessionValue returns a value from the session store
+func (w *Agent) SessionValue(key string) interface{} {
+	return w.sessionData[key]
+}
+
+// SetSessionValue stores a value in the session store, it is saved after the handler returns
+func (w *Agent) SetSessionValue(key string, value interface{}) {
+	if w.sessionData == nil {
+		w.sessionData = make(map[string]interface{})
+	}
+	w.sessionData[key] = value
+	w.sessionDirty = true
+}
+
+// DeleteSessionValue removes a value from the session store
+func (w *Agent) DeleteSessionValue(key string) {
+	delete(w.sessionData, key)
+	w.sessionDirty = true
+}
+
+// invalidContextChars matches the characters dialogflow rejects in context ids
+var invalidContextChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// stateName turns the name of framework state into a valid context id, as the
+// names contain actions like "order.create"
+func stateName(name string) string {
+	return invalidContextChars.ReplaceAllString(name, "_")
+}
+
+// loadState reads state of the framework, which is kept in the session store
+// if configured and in a context otherwise
+func (w *Agent) loadState(name string) map[string]interface{} {
+	name = stateName(name)
+	if sessionStore != nil {
+		data, _ := w.SessionValue(name).(map[string]interface{})
+		if expiredValue(data) {
+			return nil
+		}
+		return data
+	}
+	if ctx := w.inputContext(name); ctx != nil {
+		return fromStruct(ctx.Parameters)
+	}
+	return nil
+}
+
+// saveState stores state of the framework, see loadState
+func (w *Agent) saveState(name string, data map[string]interface{}) {
+	name = stateName(name)
+	if sessionStore != nil {
+		if stateTTL > 0 && !persistentState[name] {
+			data[expiresParam] = time.Now().Add(stateTTL).Format(time.RFC3339)
+		}
+		w.SetSessionValue(name, data)
+		return
+	}
+	ctx := w.OutputContext(name).SetLifespan(DefaultLifespan)
+	for key, value := range data {
+		ctx.SetParam(key, value)
+	}
+}
+
+// clearState removes state of the framework, see loadState
+func (w *Agent) clearState(name string) {
+	name = stateName(name)
+	if sessionStore != nil {
+		w.DeleteSessionValue(name)
+		return
+	}
+	if w.inputContext(name) != nil {
+		w.ClearContexts(name)
+	}
+}
@@ -0,0 +1,102 @@
+package lambdadialogflow
+
+import (
+	"sort"
+	"strings"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// suggestionContext maps the labels of suggested intents to their actions
+const suggestionContext = "lambdadialogflow-suggestions"
+
+// IntentCandidate is an intent that can be suggested after a fallback
+type IntentCandidate struct {
+	// Label is the text of the chip, e.g. "Track order"
+	Label string
+	// Action of the handler answering the selection
+	Action string
+	// Phrases are the training phrases the query is compared with
+	Phrases []string
+	// Weight scales the similarity, e.g. by the popularity of the intent.
+	// A weight of 0 counts as 1.
+	Weight float64
+}
+
+var intentCandidates []IntentCandidate
+
+// SetIntentCandidates sets the intents offered by SuggestIntents
+func SetIntentCandidates(candidates []IntentCandidate) {
+	intentCandidates = candidates
+}
+
+// SuggestIntents offers the intents closest to the query as chips, e.g. after
+// a fallback, and returns their labels. The prompt is followed by the labels,
+// as in "Did you want: Track order / Cancel order?". Selecting a chip in the
+// next turn is routed to the handler of the suggested intent.
+func (w *Agent) SuggestIntents(prompt string, max int, minScore float64) []string {
+	type scored struct {
+		candidate IntentCandidate
+		score     float64
+	}
+	var ranked []scored
+	for _, c := range intentCandidates {
+		best := 0.0
+		matches := FuzzyMatch(w.req.GetQueryResult().GetQueryText(), append([]string{c.Label}, c.Phrases...))
+		if len(matches) > 0 {
+			best = matches[0].Score
+		}
+		if c.Weight > 0 {
+			best *= c.Weight
+		}
+		if best >= minScore {
+			ranked = append(ranked, scored{candidate: c, score: best})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	if max < 0 {
+		max = 0
+	}
+	if len(ranked) > max {
+		ranked = ranked[:max]
+	}
+	if len(ranked) == 0 {
+		return nil
+	}
+
+	labels := make([]string, len(ranked))
+	actions := make([]string, len(ranked))
+	for i, r := range ranked {
+		labels[i] = r.candidate.Label
+		actions[i] = r.candidate.Action
+	}
+	w.OutputContext(suggestionContext).SetLifespan(1).SetParam("labels", labels).SetParam("actions", actions)
+	text := prompt + " " + strings.Join(labels, " / ") + "?"
+	w.Say(text)
+	// quick replies for the messengers, chips next to a simple response for actions on google
+	w.AddQuickReplies(text, labels...)
+	w.AddMessage(simpleResponse(&df.Intent_Message_SimpleResponse{TextToSpeech: text, DisplayText: text}))
+	w.AddSuggestions(labels...)
+	return labels
+}
+
+// suggestion returns the handler of a suggested intent selected by the user,
+// or nil if the query is not one of the suggestions
+func suggestion(w *Agent) WebhookHandler {
+	ctx := w.inputContext(suggestionContext)
+	if ctx == nil {
+		return nil
+	}
+	fields := ctx.GetParameters().GetFields()
+	labels := fields["labels"].GetListValue().GetValues()
+	actions := fields["actions"].GetListValue().GetValues()
+	query := normalizeText(w.req.GetQueryResult().GetQueryText())
+	for i, label := range labels {
+		if i < len(actions) && normalizeText(label.GetStringValue()) == query {
+			return w.router.handler(actions[i].GetStringValue())
+		}
+	}
+	return nil
+}
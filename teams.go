@@ -0,0 +1,87 @@
+package lambdadialogflow
+
+// teamsPayloadKey is the custom payload namespace holding microsoft teams attachments
+const teamsPayloadKey = "teams"
+
+// adaptiveCardContentType is the attachment content type of adaptive cards
+const adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+
+// AdaptiveCard builds the JSON of an adaptive card for microsoft teams
+type AdaptiveCard struct {
+	body    []interface{}
+	actions []interface{}
+}
+
+// NewAdaptiveCard creates an empty adaptive card
+func NewAdaptiveCard() *AdaptiveCard {
+	return &AdaptiveCard{}
+}
+
+// AddText adds a wrapping text block, headings are rendered large and bold
+func (c *AdaptiveCard) AddText(text string, heading bool) *AdaptiveCard {
+	block := map[string]interface{}{"type": "TextBlock", "text": text, "wrap": true}
+	if heading {
+		block["size"] = "Large"
+		block["weight"] = "Bolder"
+	}
+	c.body = append(c.body, block)
+	return c
+}
+
+// AddImage adds an image with alternative text
+func (c *AdaptiveCard) AddImage(url, altText string) *AdaptiveCard {
+	c.body = append(c.body, map[string]interface{}{"type": "Image", "url": url, "altText": altText})
+	return c
+}
+
+// AddFacts adds a fact set from alternating titles and values
+func (c *AdaptiveCard) AddFacts(titlesAndValues ...string) *AdaptiveCard {
+	var facts []interface{}
+	for i := 0; i+1 < len(titlesAndValues); i += 2 {
+		facts = append(facts, map[string]interface{}{"title": titlesAndValues[i], "value": titlesAndValues[i+1]})
+	}
+	c.body = append(c.body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	return c
+}
+
+// AddOpenURL adds a button opening a url
+func (c *AdaptiveCard) AddOpenURL(title, url string) *AdaptiveCard {
+	c.actions = append(c.actions, map[string]interface{}{"type": "Action.OpenUrl", "title": title, "url": url})
+	return c
+}
+
+// AddSubmit adds a button sending data back to the bot, the relay passes it on
+// to dialogflow as query text or event
+func (c *AdaptiveCard) AddSubmit(title string, data map[string]interface{}) *AdaptiveCard {
+	c.actions = append(c.actions, map[string]interface{}{"type": "Action.Submit", "title": title, "data": data})
+	return c
+}
+
+// Map returns the JSON structure of the card
+func (c *AdaptiveCard) Map() map[string]interface{} {
+	card := map[string]interface{}{
+		"type":    "AdaptiveCard",
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"version": "1.2",
+		"body":    c.body,
+	}
+	if len(c.actions) > 0 {
+		card["actions"] = c.actions
+	}
+	return card
+}
+
+// AddAdaptiveCard adds the card to payload.teams.attachments in the format of
+// bot framework activity attachments, so a relay can forward it unchanged
+func (w *Agent) AddAdaptiveCard(card *AdaptiveCard) {
+	teams, ok := fromStruct(w.res.GetPayload())[teamsPayloadKey].(map[string]interface{})
+	if !ok {
+		teams = make(map[string]interface{})
+	}
+	attachments, _ := teams["attachments"].([]interface{})
+	teams["attachments"] = append(attachments, map[string]interface{}{
+		"contentType": adaptiveCardContentType,
+		"content":     card.Map(),
+	})
+	w.AddPayloadStruct(teamsPayloadKey, teams)
+}
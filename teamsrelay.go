@@ -0,0 +1,97 @@
+package lambdadialogflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// TeamsActivity is the part of a bot framework activity used by the TeamsRelay
+type TeamsActivity struct {
+	Type         string                 `json:"type"`
+	ID           string                 `json:"id,omitempty"`
+	Text         string                 `json:"text,omitempty"`
+	Value        map[string]interface{} `json:"value,omitempty"`
+	Locale       string                 `json:"locale,omitempty"`
+	ServiceURL   string                 `json:"serviceUrl,omitempty"`
+	From         *TeamsAccount          `json:"from,omitempty"`
+	Recipient    *TeamsAccount          `json:"recipient,omitempty"`
+	Conversation *TeamsAccount          `json:"conversation,omitempty"`
+	ReplyToID    string                 `json:"replyToId,omitempty"`
+	Attachments  []interface{}          `json:"attachments,omitempty"`
+}
+
+// TeamsAccount identifies a user, bot or conversation of an activity
+type TeamsAccount struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// TeamsRelay forwards teams messages to dialogflow's detectIntent API and
+// turns the query results into reply activities. The bot posts the replies to
+// the conversation at the ServiceURL of the activity.
+type TeamsRelay struct {
+	Client *Client
+	// Agent is the resource name of the agent, e.g. "projects/my-project/agent"
+	Agent string
+	// LanguageCode is used for activities without locale
+	LanguageCode string
+}
+
+// Reply sends a message activity to dialogflow and returns the reply. Data of
+// Action.Submit buttons triggers its "event" with the other fields as
+// parameters, or is sent as query if it has a "text" field.
+func (r *TeamsRelay) Reply(activity TeamsActivity) (TeamsActivity, error) {
+	if activity.Conversation == nil {
+		return TeamsActivity{}, errors.New("activity without conversation")
+	}
+	languageCode := activity.Locale
+	if languageCode == "" {
+		languageCode = r.LanguageCode
+	}
+	session := r.session(activity.Conversation.ID)
+
+	var res *df.DetectIntentResponse
+	var err error
+	if event, ok := activity.Value["event"].(string); ok {
+		params := make(map[string]interface{}, len(activity.Value))
+		for key, value := range activity.Value {
+			if key != "event" {
+				params[key] = value
+			}
+		}
+		res, err = r.Client.DetectEvent(session, event, languageCode, params)
+	} else {
+		text := activity.Text
+		if t, ok := activity.Value["text"].(string); ok {
+			text = t
+		}
+		res, err = r.Client.DetectText(session, text, languageCode)
+	}
+	if err != nil {
+		return TeamsActivity{}, err
+	}
+
+	teams, _ := fromStruct(res.GetQueryResult().GetWebhookPayload())[teamsPayloadKey].(map[string]interface{})
+	attachments, _ := teams["attachments"].([]interface{})
+	return TeamsActivity{
+		Type:         "message",
+		Text:         res.GetQueryResult().GetFulfillmentText(),
+		Locale:       activity.Locale,
+		ServiceURL:   activity.ServiceURL,
+		From:         activity.Recipient,
+		Recipient:    activity.From,
+		Conversation: activity.Conversation,
+		ReplyToID:    activity.ID,
+		Attachments:  attachments,
+	}, nil
+}
+
+// session returns the dialogflow session of a teams conversation. Teams
+// conversation ids are longer than the 36 characters allowed for session ids.
+func (r *TeamsRelay) session(conversation string) string {
+	sum := sha256.Sum256([]byte(conversation))
+	return r.Agent + "/sessions/" + hex.EncodeToString(sum[:16])
+}
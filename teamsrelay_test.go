@@ -0,0 +1,70 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// staticToken is a TokenSource returning a fixed token
+type staticToken string
+
+func (t staticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+func TestTeamsRelay(t *testing.T) {
+	var queries []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, "/projects/p/agent/sessions/") || !strings.HasSuffix(req.URL.Path, ":detectIntent") {
+			t.Errorf("path = %v", req.URL.Path)
+		}
+		var body map[string]interface{}
+		b, _ := ioutil.ReadAll(req.Body)
+		json.Unmarshal(b, &body)
+		queries = append(queries, body["queryInput"].(map[string]interface{}))
+		rw.Write([]byte(`{"queryResult":{"fulfillmentText":"Your order","webhookPayload":{"teams":{"attachments":[` +
+			`{"contentType":"application/vnd.microsoft.card.adaptive","content":{"type":"AdaptiveCard"}}]}}}}`))
+	}))
+	defer srv.Close()
+
+	relay := &TeamsRelay{
+		Client:       &Client{HTTPClient: srv.Client(), Tokens: staticToken("token"), Endpoint: srv.URL},
+		Agent:        "projects/p/agent",
+		LanguageCode: "en",
+	}
+	activity := TeamsActivity{
+		Type:         "message",
+		ID:           "1",
+		Text:         "where is my order",
+		From:         &TeamsAccount{ID: "user"},
+		Recipient:    &TeamsAccount{ID: "bot"},
+		Conversation: &TeamsAccount{ID: "a:1" + strings.Repeat("x", 100)},
+	}
+	reply, err := relay.Reply(activity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Text != "Your order" || reply.ReplyToID != "1" || reply.Recipient.ID != "user" || reply.From.ID != "bot" {
+		t.Errorf("reply = %+v", reply)
+	}
+	if len(reply.Attachments) != 1 || reply.Attachments[0].(map[string]interface{})["contentType"] != adaptiveCardContentType {
+		t.Errorf("attachments = %v", reply.Attachments)
+	}
+
+	activity.Text = ""
+	activity.Value = map[string]interface{}{"event": "track_order", "order": "42"}
+	if _, err := relay.Reply(activity); err != nil {
+		t.Fatal(err)
+	}
+	if text := queries[0]["text"].(map[string]interface{}); text["text"] != "where is my order" || text["languageCode"] != "en" {
+		t.Errorf("text query = %v", queries[0])
+	}
+	event := queries[1]["event"].(map[string]interface{})
+	if event["name"] != "track_order" || event["parameters"].(map[string]interface{})["order"] != "42" {
+		t.Errorf("event query = %v", queries[1])
+	}
+}
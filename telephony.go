@@ -0,0 +1,111 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// telephonyPayloadKey is the payload key holding the settings for phone gateways
+const telephonyPayloadKey = "telephony"
+
+// setTelephony merges the fields into the telephony section of the response payload
+func (w *Agent) setTelephony(fields map[string]interface{}) {
+	telephony, ok := fromStruct(w.res.GetPayload())[telephonyPayloadKey].(map[string]interface{})
+	if !ok {
+		telephony = make(map[string]interface{})
+	}
+	for name, value := range fields {
+		telephony[name] = value
+	}
+	w.AddPayloadStruct(telephonyPayloadKey, telephony)
+}
+
+// SetBargeIn allows or prevents the caller from interrupting the prompt of
+// this turn, e.g. to let regular callers skip a long menu
+func (w *Agent) SetBargeIn(enabled bool) {
+	w.setTelephony(map[string]interface{}{"bargeIn": enabled})
+}
+
+// SetEndpointing tunes the end of speech detection for the answer to this
+// turn's prompt. Sensitivity ranges from 0 (wait for long pauses, e.g. while
+// the caller reads a number) to 1 (end quickly after short answers), the
+// timeout ends the turn when the caller does not start speaking.
+func (w *Agent) SetEndpointing(sensitivity float64, noInputTimeout time.Duration) error {
+	if sensitivity < 0 || sensitivity > 1 {
+		return fmt.Errorf("end of speech sensitivity %v is not between 0 and 1", sensitivity)
+	}
+	fields := map[string]interface{}{"endOfSpeechSensitivity": sensitivity}
+	if noInputTimeout > 0 {
+		fields["noInputTimeoutMs"] = noInputTimeout.Nanoseconds() / int64(time.Millisecond)
+	}
+	w.setTelephony(fields)
+	return nil
+}
+
+// telephonySource is the source of requests coming from dialogflow's phone gateway
+const telephonySource = "GOOGLE_TELEPHONY"
+
+// DTMF returns the keys the caller pressed on the keypad. Gateways passing the
+// digits in the payload are preferred, otherwise a query text consisting only
+// of keypad characters on a telephony request counts as DTMF input.
+func (w *Agent) DTMF() (string, bool) {
+	for _, keys := range [][]string{{"telephony", "dtmf_digits"}, {"telephony", "dtmfDigits"}, {"dtmfDigits"}} {
+		if digits := w.payloadString(keys...); digits != "" {
+			return digits, true
+		}
+	}
+	if w.Source() != SourcePhoneGateway {
+		return "", false
+	}
+	text := strings.Replace(w.req.GetQueryResult().GetQueryText(), " ", "", -1)
+	if text == "" || strings.Trim(text, "0123456789*#") != "" {
+		return "", false
+	}
+	return text, true
+}
+
+// MenuOption is one choice of a phone menu, selectable by key or by saying its label
+type MenuOption struct {
+	Key   string
+	Label string
+	Value string
+}
+
+// MenuPrompt renders the options consistently with format, which receives the
+// key and the label of each option, e.g. "Press %s for %s."
+func MenuPrompt(format string, options []MenuOption) string {
+	lines := make([]string, 0, len(options))
+	for _, option := range options {
+		lines = append(lines, fmt.Sprintf(format, option.Key, option.Label))
+	}
+	return strings.Join(lines, " ")
+}
+
+// SelectOption maps the caller's input onto one of the options, either by the
+// pressed key or by fuzzy matching the spoken label
+func (w *Agent) SelectOption(options []MenuOption) (MenuOption, bool) {
+	if digits, ok := w.DTMF(); ok {
+		for _, option := range options {
+			if option.Key == digits {
+				return option, true
+			}
+		}
+		return MenuOption{}, false
+	}
+
+	labels := make([]string, 0, len(options))
+	for _, option := range options {
+		labels = append(labels, option.Label)
+	}
+	matches := w.MatchQuery(labels, 0.8)
+	if len(matches) == 0 {
+		return MenuOption{}, false
+	}
+	for _, option := range options {
+		if option.Label == matches[0].Value {
+			return option, true
+		}
+	}
+	return MenuOption{}, false
+}
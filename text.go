@@ -0,0 +1,31 @@
+package lambdadialogflow
+
+import df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+
+// Platform is the integration a fulfillment message is meant for
+type Platform = df.Intent_Message_Platform
+
+// Platforms of fulfillment messages
+const (
+	PlatformDefault  Platform = df.Intent_Message_PLATFORM_UNSPECIFIED
+	PlatformFacebook Platform = df.Intent_Message_FACEBOOK
+	PlatformSlack    Platform = df.Intent_Message_SLACK
+	PlatformTelegram Platform = df.Intent_Message_TELEGRAM
+	PlatformKik      Platform = df.Intent_Message_KIK
+	PlatformSkype    Platform = df.Intent_Message_SKYPE
+	PlatformLine     Platform = df.Intent_Message_LINE
+	PlatformViber    Platform = df.Intent_Message_VIBER
+	PlatformGoogle   Platform = df.Intent_Message_ACTIONS_ON_GOOGLE
+)
+
+// AddText appends a text bubble for the given platforms, or for all platforms
+// without messages of their own if none is given. Unlike Say it can be called
+// several times to send multiple bubbles.
+func (w *Agent) AddText(text string, platforms ...Platform) {
+	if len(platforms) == 0 {
+		platforms = []Platform{PlatformDefault}
+	}
+	for _, platform := range platforms {
+		w.AddMessage(textMessage(platform, text))
+	}
+}
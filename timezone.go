@@ -0,0 +1,62 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"time"
+	// the provided lambda runtimes come without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// Location returns the time zone of the user. It is taken from the payload of
+// the original request (time zone names sent by custom integrations or the
+// device time zone of actions on google) and defaults to UTC.
+func (w *Agent) Location() *time.Location {
+	if loc, ok := w.userLocation(); ok {
+		return loc
+	}
+	return time.UTC
+}
+
+// userLocation returns the time zone sent with the request, if any
+func (w *Agent) userLocation() (*time.Location, bool) {
+	for _, name := range []string{
+		w.payloadString("timeZone"),
+		w.payloadString("time_zone"),
+		w.payloadString("device", "timeZone", "id"),
+	} {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, true
+		}
+	}
+	return nil, false
+}
+
+// timeLayouts are the formats dialogflow uses for date and time parameters
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"15:04:05",
+}
+
+// parseTime parses a date or time parameter. Values without a zone offset are
+// interpreted in the time zone of the user instead of UTC. Dialogflow resolves
+// "tomorrow at noon" in the time zone of the agent, so the wall clock of values
+// with an offset is moved into the time zone of the user, if it is known.
+func (w *Agent) parseTime(value string) (time.Time, error) {
+	loc := w.Location()
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			continue
+		}
+		if userLoc, ok := w.userLocation(); ok && t.Location() != userLoc {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), userLoc)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unable to parse time: %v", value)
+}
@@ -0,0 +1,29 @@
+package lambdadialogflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeUserZone(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	tests := []struct {
+		value    string
+		timeZone string
+		want     time.Time
+	}{
+		// the agent resolved "tomorrow at noon" in Europe/Berlin
+		{"2020-05-01T12:00:00+02:00", "America/New_York", time.Date(2020, 5, 1, 12, 0, 0, 0, newYork)},
+		{"2020-05-01T12:00:00+02:00", "", time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"2020-05-01T09:30:00", "America/New_York", time.Date(2020, 5, 1, 9, 30, 0, 0, newYork)},
+		{"2020-05-01", "America/New_York", time.Date(2020, 5, 1, 0, 0, 0, 0, newYork)},
+	}
+	for _, test := range tests {
+		w := testRequest(t, `{"responseId":"1","session":"`+testSession+`",`+
+			`"queryResult":{"action":"remind"},"originalDetectIntentRequest":{"payload":{"timeZone":"`+test.timeZone+`"}}}`)
+		got, err := w.parseTime(test.value)
+		if err != nil || !got.Equal(test.want) {
+			t.Errorf("parseTime(%v) in %q = %v, %v, want %v", test.value, test.timeZone, got, err, test.want)
+		}
+	}
+}
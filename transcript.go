@@ -0,0 +1,132 @@
+package lambdadialogflow
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// TranscriptFormat is a file format for exported conversations
+type TranscriptFormat string
+
+// Transcript formats
+const (
+	// TranscriptCSV writes one row per turn with a header row, e.g. for spreadsheets
+	TranscriptCSV TranscriptFormat = "csv"
+	// TranscriptJSONL writes one JSON object per turn and line
+	TranscriptJSONL TranscriptFormat = "jsonl"
+	// TranscriptDialogflow writes the conversations like the dialogflow
+	// history: a JSON document with the turns grouped by session, each with
+	// the query result as returned by the detectIntent API
+	TranscriptDialogflow TranscriptFormat = "dialogflow"
+)
+
+// transcriptColumns are the columns of CSV transcripts
+var transcriptColumns = []string{"session", "turn", "time", "query", "intent", "action", "confidence", "response"}
+
+// ExportTranscript writes the turns in the given format, so conversations can
+// be reviewed without access to the raw logs
+func ExportTranscript(out io.Writer, format TranscriptFormat, turns []Turn) error {
+	switch format {
+	case TranscriptCSV:
+		w := csv.NewWriter(out)
+		w.Write(transcriptColumns)
+		for _, t := range turns {
+			w.Write([]string{
+				t.Session,
+				strconv.Itoa(t.Turn),
+				t.Time.Format(time.RFC3339),
+				t.QueryText,
+				t.Intent,
+				t.Action,
+				strconv.FormatFloat(float64(t.Confidence), 'f', -1, 32),
+				t.Response,
+			})
+		}
+		w.Flush()
+		return w.Error()
+	case TranscriptJSONL:
+		enc := json.NewEncoder(out)
+		for _, t := range turns {
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TranscriptDialogflow:
+		return exportDialogflowHistory(out, turns)
+	}
+	return fmt.Errorf("unsupported transcript format: %v", format)
+}
+
+// historyConversation is a conversation of the dialogflow history format
+type historyConversation struct {
+	Session      string               `json:"session"`
+	Interactions []historyInteraction `json:"interactions"`
+}
+
+// historyInteraction is a turn of the dialogflow history format
+type historyInteraction struct {
+	Timestamp   string          `json:"timestamp"`
+	QueryResult json.RawMessage `json:"queryResult"`
+}
+
+// exportDialogflowHistory writes the turns in the dialogflow history format,
+// conversations ordered by their first turn
+func exportDialogflowHistory(out io.Writer, turns []Turn) error {
+	var conversations []*historyConversation
+	bySession := make(map[string]*historyConversation)
+	marshaler := &jsonpb.Marshaler{}
+	for _, t := range turns {
+		conversation := bySession[t.Session]
+		if conversation == nil {
+			conversation = &historyConversation{Session: t.Session, Interactions: []historyInteraction{}}
+			bySession[t.Session] = conversation
+			conversations = append(conversations, conversation)
+		}
+		result, err := marshaler.MarshalToString(&df.QueryResult{
+			QueryText:                 t.QueryText,
+			Action:                    t.Action,
+			Parameters:                toValue(t.Params).GetStructValue(),
+			FulfillmentText:           t.Response,
+			Intent:                    &df.Intent{DisplayName: t.Intent},
+			IntentDetectionConfidence: t.Confidence,
+		})
+		if err != nil {
+			return err
+		}
+		conversation.Interactions = append(conversation.Interactions, historyInteraction{
+			Timestamp:   t.Time.UTC().Format(time.RFC3339Nano),
+			QueryResult: json.RawMessage(result),
+		})
+	}
+	if conversations == nil {
+		conversations = []*historyConversation{}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{"conversations": conversations})
+}
+
+// ExportSession writes up to limit turns of a session from the conversation
+// log in the given format, oldest first
+func ExportSession(out io.Writer, format TranscriptFormat, session string, limit int) error {
+	if conversationLog == nil {
+		return errors.New("no conversation log configured")
+	}
+	turns, err := conversationLog.History(session, limit)
+	if err != nil {
+		return fmt.Errorf("unable to load history: %v", err)
+	}
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	return ExportTranscript(out, format, turns)
+}
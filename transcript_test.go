@@ -0,0 +1,51 @@
+package lambdadialogflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestExportTranscriptDialogflow(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	turns := []Turn{
+		{Session: "a", Turn: 1, Time: at, QueryText: "hi", Intent: "welcome", Action: "greet", Confidence: 0.5, Response: "hello"},
+		{Session: "b", Turn: 1, Time: at, QueryText: "order", Intent: "order", Params: map[string]interface{}{"size": "xl"}},
+		{Session: "a", Turn: 2, Time: at.Add(time.Minute), QueryText: "bye", Intent: "goodbye"},
+	}
+	var buf bytes.Buffer
+	if err := ExportTranscript(&buf, TranscriptDialogflow, turns); err != nil {
+		t.Fatal(err)
+	}
+	var history struct {
+		Conversations []struct {
+			Session      string
+			Interactions []struct {
+				Timestamp   string
+				QueryResult struct {
+					QueryText                 string
+					Action                    string
+					Parameters                map[string]interface{}
+					FulfillmentText           string
+					IntentDetectionConfidence float64
+					Intent                    struct{ DisplayName string }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Conversations) != 2 || history.Conversations[0].Session != "a" || len(history.Conversations[0].Interactions) != 2 {
+		t.Fatalf("conversations = %+v", history.Conversations)
+	}
+	first := history.Conversations[0].Interactions[0]
+	if first.Timestamp != "2020-01-02T03:04:05Z" || first.QueryResult.QueryText != "hi" || first.QueryResult.Intent.DisplayName != "welcome" ||
+		first.QueryResult.FulfillmentText != "hello" || first.QueryResult.IntentDetectionConfidence != 0.5 {
+		t.Errorf("first interaction = %+v", first)
+	}
+	if size := history.Conversations[1].Interactions[0].QueryResult.Parameters["size"]; size != "xl" {
+		t.Errorf("parameter size = %v", size)
+	}
+}
@@ -0,0 +1,90 @@
+package lambdadialogflow
+
+import (
+	"net/http"
+	"time"
+
+	df "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+// UsageEvent is the analytics event carrying the usage of a turn
+const UsageEvent = "turn_usage"
+
+// AccountUsage returns a middleware recording the usage of every turn: handler
+// CPU and wall time, downstream calls and response size. The usage is emitted
+// as metrics and as UsageEvent attributed to the tenant, e.g. the team owning
+// the intent. CPU time is measured for the whole process, so it is only exact
+// when the process serves one request at a time like on lambda. The response
+// size is the size of the marshaled body, after response transformers ran.
+func AccountUsage(tenant func(*Agent) string) Middleware {
+	return func(handler WebhookHandler) WebhookHandler {
+		return func(w *Agent) {
+			start, cpuStart := time.Now(), cpuTime()
+			handler(w)
+			w.usage = &turnUsage{tenant: tenant(w), wall: time.Since(start), cpu: cpuTime() - cpuStart}
+		}
+	}
+}
+
+// turnUsage is the usage of a turn recorded by AccountUsage
+type turnUsage struct {
+	tenant string
+	cpu    time.Duration
+	wall   time.Duration
+}
+
+// reportUsage emits the usage recorded by AccountUsage once the response is
+// final. res is nil for turns answered with an http error.
+func (w *Agent) reportUsage(res *df.WebhookResponse) {
+	usage := w.usage
+	if usage == nil {
+		return
+	}
+	w.usage = nil
+	size := 0
+	if res != nil {
+		if body, err := marshalResponse(res); err == nil {
+			size = len(body)
+		}
+	}
+	w.emitMetrics(
+		metric{Name: "HandlerCPUTime", Unit: "Milliseconds", value: float64(usage.cpu) / float64(time.Millisecond)},
+		metric{Name: "HandlerTime", Unit: "Milliseconds", value: float64(usage.wall) / float64(time.Millisecond)},
+		metric{Name: "DownstreamCalls", Unit: "Count", value: float64(w.calls)},
+		metric{Name: "BytesOut", Unit: "Bytes", value: float64(size)},
+	)
+	w.TrackGoal(UsageEvent, map[string]interface{}{
+		"tenant":          usage.tenant,
+		"cpuMillis":       usage.cpu.Milliseconds(),
+		"wallMillis":      usage.wall.Milliseconds(),
+		"downstreamCalls": w.calls,
+		"bytesOut":        size,
+	})
+}
+
+// CountCall counts a downstream call of the turn for AccountUsage. Calls of
+// clients obtained by Client.For and attachment downloads are counted
+// automatically.
+func (w *Agent) CountCall() {
+	w.calls++
+}
+
+// Transport wraps base, counting its requests as downstream calls of the turn
+func (w *Agent) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return countingTransport{w: w, base: base}
+}
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	w    *Agent
+	base http.RoundTripper
+}
+
+// RoundTrip counts and sends the request
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.w.CountCall()
+	return t.base.RoundTrip(req)
+}
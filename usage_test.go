@@ -0,0 +1,41 @@
+package lambdadialogflow
+
+import (
+	"strings"
+	"testing"
+)
+
+// recordingExporter is an AnalyticsExporter keeping the events
+type recordingExporter struct {
+	events []Event
+}
+
+func (e *recordingExporter) Export(events []Event) error {
+	e.events = append(e.events, events...)
+	return nil
+}
+
+func TestAccountUsageBytesOut(t *testing.T) {
+	exporter := &recordingExporter{}
+	SetAnalyticsExporter(exporter)
+	defer SetAnalyticsExporter(nil)
+
+	r := NewRouter()
+	r.Use(AccountUsage(func(*Agent) string { return "team" }))
+	r.Transform(func(w *Agent) {
+		w.Say(w.Response().FulfillmentText + strings.Repeat(" and more", 20))
+	})
+	r.Register("hello", func(w *Agent) { w.Say("hello") })
+	res := newConversation(t, r).say("hello", "hi", nil)
+
+	if len(exporter.events) != 1 || exporter.events[0].Name != UsageEvent {
+		t.Fatalf("events = %+v", exporter.events)
+	}
+	body, err := marshalResponse(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := exporter.events[0].Props["bytesOut"]; size != len(body) {
+		t.Errorf("bytesOut = %v, want %v", size, len(body))
+	}
+}
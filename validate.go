@@ -0,0 +1,117 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// NormalizePhoneNumber converts a @sys.phone-number value into E.164 format.
+// Numbers without international prefix get the country calling code
+// prepended, e.g. "49" turns "0171 1234567" into "+491711234567". Numbers
+// already starting with the calling code, like "49 171 1234567", keep it.
+func NormalizePhoneNumber(number, callingCode string) (string, error) {
+	var digits strings.Builder
+	for i, r := range strings.TrimSpace(number) {
+		switch {
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			digits.WriteRune(r)
+		case strings.ContainsRune(" -./()", r):
+		default:
+			return "", fmt.Errorf("invalid character %q in phone number", r)
+		}
+	}
+
+	n := digits.String()
+	switch {
+	case strings.HasPrefix(n, "+"):
+		n = n[1:]
+	case strings.HasPrefix(n, "00"):
+		n = n[2:]
+	case strings.HasPrefix(n, "0"):
+		if callingCode == "" {
+			return "", fmt.Errorf("phone number %v has no country code", number)
+		}
+		n = strings.TrimPrefix(callingCode, "+") + n[1:]
+	default:
+		code := strings.TrimPrefix(callingCode, "+")
+		// subscriber numbers have at least 7 digits, shorter remainders are
+		// national numbers which happen to start like the calling code
+		if !strings.HasPrefix(n, code) || len(n)-len(code) < 7 {
+			n = code + n
+		}
+	}
+
+	if len(n) < 8 || len(n) > 15 || n[0] == '0' {
+		return "", fmt.Errorf("invalid phone number: %v", number)
+	}
+	return "+" + n, nil
+}
+
+// PhoneNumber returns a validator for ValidParam normalizing phone numbers
+// with the country calling code, see NormalizePhoneNumber
+func PhoneNumber(callingCode string) func(string) (string, error) {
+	return func(number string) (string, error) {
+		return NormalizePhoneNumber(number, callingCode)
+	}
+}
+
+// ValidateEmail checks the syntax of a @sys.email value and returns it
+// trimmed with a lowercase domain
+func ValidateEmail(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	at := strings.LastIndex(address, "@")
+	if at < 1 || at == len(address)-1 || strings.ContainsAny(address, " \t,;<>") {
+		return "", fmt.Errorf("invalid email address: %v", address)
+	}
+	local, domain := address[:at], strings.ToLower(address[at+1:])
+	if len(local) > 64 || strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return "", fmt.Errorf("invalid email address: %v", address)
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 || len(labels[len(labels)-1]) < 2 {
+		return "", fmt.Errorf("invalid email domain: %v", domain)
+	}
+	for _, label := range labels {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", fmt.Errorf("invalid email domain: %v", domain)
+		}
+	}
+	return local + "@" + domain, nil
+}
+
+// Reprompt discards the value of a parameter collected by slot filling and
+// asks for it again with the given text
+func (w *Agent) Reprompt(param, text string) {
+	for _, ctx := range w.req.GetQueryResult().GetOutputContexts() {
+		fields := ctx.GetParameters().GetFields()
+		if _, ok := fields[param]; !ok {
+			continue
+		}
+		out := w.OutputContext(ctx.Name).SetLifespan(ctx.LifespanCount)
+		for name, value := range fields {
+			out.SetParam(name, value)
+		}
+		out.SetParam(param, "")
+		out.SetParam(param+".original", "")
+	}
+	w.Say(text)
+}
+
+// ValidParam returns a string parameter passed through validate, typically
+// PhoneNumber("49") or ValidateEmail. Invalid values are discarded and the
+// user is asked again with prompt.
+func (w *Agent) ValidParam(param string, validate func(string) (string, error), prompt string) (string, bool) {
+	value := w.GetStringParam(param)
+	if value == "" {
+		return "", false
+	}
+	valid, err := validate(value)
+	if err != nil {
+		w.Reprompt(param, prompt)
+		return "", false
+	}
+	return valid, true
+}
@@ -0,0 +1,47 @@
+package lambdadialogflow
+
+import "testing"
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		number, callingCode string
+		want                string
+		ok                  bool
+	}{
+		{"0171 1234567", "49", "+491711234567", true},
+		{"+49 171 1234567", "49", "+491711234567", true},
+		{"0049 (171) 123-4567", "49", "+491711234567", true},
+		{"491711234567", "49", "+491711234567", true},
+		{"4930123456", "+49", "+4930123456", true},
+		{"1711234567", "49", "+491711234567", true},
+		{"4912345", "49", "+494912345", true},
+		{"0171 1234567", "", "", false},
+		{"0171 123456a", "49", "", false},
+		{"123", "49", "", false},
+	}
+	for _, test := range tests {
+		got, err := NormalizePhoneNumber(test.number, test.callingCode)
+		if got != test.want || (err == nil) != test.ok {
+			t.Errorf("NormalizePhoneNumber(%q, %q) = %q, %v, want %q", test.number, test.callingCode, got, err, test.want)
+		}
+	}
+}
+
+func TestValidParamPhoneNumber(t *testing.T) {
+	w := testAgent(t, `{"action":"callback","parameters":{"phone":"0171 1234567"}}`)
+	if got, ok := w.ValidParam("phone", PhoneNumber("49"), "Which number?"); got != "+491711234567" || !ok {
+		t.Errorf("ValidParam = %q, %v", got, ok)
+	}
+
+	w = testAgent(t, `{"action":"callback","parameters":{"phone":"0171 123456a"},`+
+		`"outputContexts":[{"name":"`+testSession+`/contexts/callback_dialog_params_phone","lifespanCount":1,"parameters":{"phone":"0171 123456a"}}]}`)
+	if got, ok := w.ValidParam("phone", PhoneNumber("49"), "Which number?"); got != "" || ok {
+		t.Errorf("ValidParam of invalid number = %q, %v", got, ok)
+	}
+	if w.Response().FulfillmentText != "Which number?" {
+		t.Errorf("prompt = %q", w.Response().FulfillmentText)
+	}
+	if len(w.Response().OutputContexts) != 1 {
+		t.Errorf("slot filling context not reset: %v", w.Response().OutputContexts)
+	}
+}
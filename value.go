@@ -0,0 +1,97 @@
+package lambdadialogflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	_structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// toValue converts nested maps, slices and primitives into a protobuf value.
+// Other types like structs are converted through their JSON encoding, values
+// which cannot be encoded become null.
+func toValue(v interface{}) *_structpb.Value {
+	switch t := v.(type) {
+	case nil:
+		return &_structpb.Value{Kind: &_structpb.Value_NullValue{}}
+	case *_structpb.Value:
+		return t
+	case *_structpb.Struct:
+		return &_structpb.Value{Kind: &_structpb.Value_StructValue{StructValue: t}}
+	case *_structpb.ListValue:
+		return &_structpb.Value{Kind: &_structpb.Value_ListValue{ListValue: t}}
+	case string:
+		return &_structpb.Value{Kind: &_structpb.Value_StringValue{StringValue: t}}
+	case bool:
+		return &_structpb.Value{Kind: &_structpb.Value_BoolValue{BoolValue: t}}
+	case fmt.Stringer:
+		return &_structpb.Value{Kind: &_structpb.Value_StringValue{StringValue: t.String()}}
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &_structpb.Value{Kind: &_structpb.Value_NumberValue{NumberValue: float64(rv.Int())}}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &_structpb.Value{Kind: &_structpb.Value_NumberValue{NumberValue: float64(rv.Uint())}}
+	case reflect.Float32, reflect.Float64:
+		return &_structpb.Value{Kind: &_structpb.Value_NumberValue{NumberValue: rv.Float()}}
+	case reflect.Slice, reflect.Array:
+		list := &_structpb.ListValue{}
+		for i := 0; i < rv.Len(); i++ {
+			list.Values = append(list.Values, toValue(rv.Index(i).Interface()))
+		}
+		return &_structpb.Value{Kind: &_structpb.Value_ListValue{ListValue: list}}
+	case reflect.Map:
+		s := &_structpb.Struct{Fields: make(map[string]*_structpb.Value, rv.Len())}
+		for _, key := range rv.MapKeys() {
+			s.Fields[fmt.Sprint(key.Interface())] = toValue(rv.MapIndex(key).Interface())
+		}
+		return &_structpb.Value{Kind: &_structpb.Value_StructValue{StructValue: s}}
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return toValue(nil)
+		}
+		return toValue(rv.Elem().Interface())
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return toValue(nil)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return toValue(nil)
+	}
+	return toValue(decoded)
+}
+
+// fromValue converts a protobuf value into nested maps, slices and primitives
+func fromValue(v *_structpb.Value) interface{} {
+	switch k := v.GetKind().(type) {
+	case *_structpb.Value_StringValue:
+		return k.StringValue
+	case *_structpb.Value_NumberValue:
+		return k.NumberValue
+	case *_structpb.Value_BoolValue:
+		return k.BoolValue
+	case *_structpb.Value_StructValue:
+		return fromStruct(k.StructValue)
+	case *_structpb.Value_ListValue:
+		list := make([]interface{}, 0, len(k.ListValue.GetValues()))
+		for _, item := range k.ListValue.GetValues() {
+			list = append(list, fromValue(item))
+		}
+		return list
+	}
+	return nil
+}
+
+// fromStruct converts a protobuf struct into a map
+func fromStruct(s *_structpb.Struct) map[string]interface{} {
+	m := make(map[string]interface{}, len(s.GetFields()))
+	for name, field := range s.GetFields() {
+		m[name] = fromValue(field)
+	}
+	return m
+}
@@ -0,0 +1,28 @@
+package lambdadialogflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToValueStruct(t *testing.T) {
+	type item struct {
+		Name     string   `json:"name"`
+		Price    float64  `json:"price"`
+		Tags     []string `json:"tags,omitempty"`
+		internal int
+	}
+	got := fromValue(toValue(map[string]interface{}{
+		"item":  item{Name: "pizza", Price: 9.5, internal: 1},
+		"items": []item{{Name: "pasta", Tags: []string{"veggie"}}},
+		"ch":    make(chan int),
+	}))
+	want := map[string]interface{}{
+		"item":  map[string]interface{}{"name": "pizza", "price": 9.5},
+		"items": []interface{}{map[string]interface{}{"name": "pasta", "price": 0.0, "tags": []interface{}{"veggie"}}},
+		"ch":    nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("toValue = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package lambdadialogflow
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// twilioPayloadKey is the custom payload key read by the twilio integration.
+// The fields mirror the parameters of twilio's Messages API.
+const twilioPayloadKey = "twilio"
+
+// WhatsApp limits for interactive quick reply buttons
+const (
+	WhatsAppMaxButtons     = 3
+	WhatsAppMaxButtonTitle = 20
+)
+
+// twilioPayload returns the twilio section of the response payload, creating it if needed
+func (w *Agent) twilioPayload() map[string]interface{} {
+	payload := fromStruct(w.res.GetPayload())
+	twilio, ok := payload[twilioPayloadKey].(map[string]interface{})
+	if !ok {
+		twilio = make(map[string]interface{})
+	}
+	return twilio
+}
+
+// setTwilioPayload merges the fields into the twilio section of the response payload
+func (w *Agent) setTwilioPayload(fields map[string]interface{}) {
+	twilio := w.twilioPayload()
+	for name, value := range fields {
+		twilio[name] = value
+	}
+	w.AddPayloadStruct(twilioPayloadKey, twilio)
+}
+
+// AddWhatsAppMedia attaches an image, video or document to the WhatsApp reply.
+// The fulfillment text is sent as caption.
+func (w *Agent) AddWhatsAppMedia(mediaURL string) {
+	twilio := w.twilioPayload()
+	urls, _ := twilio["mediaUrl"].([]interface{})
+	w.setTwilioPayload(map[string]interface{}{"mediaUrl": append(urls, mediaURL)})
+}
+
+// SetWhatsAppTemplate replies with an approved WhatsApp message template,
+// identified by the twilio content sid, required outside of the 24 hour session window
+func (w *Agent) SetWhatsAppTemplate(contentSid string, variables map[string]string) {
+	w.setTwilioPayload(map[string]interface{}{
+		"contentSid":       contentSid,
+		"contentVariables": variables,
+	})
+}
+
+// SetWhatsAppButtons replies with a quick reply content template, passing the
+// button titles as content variables 1..n. WhatsApp allows up to three buttons
+// with at most 20 characters each.
+func (w *Agent) SetWhatsAppButtons(contentSid string, buttons ...string) error {
+	if len(buttons) == 0 || len(buttons) > WhatsAppMaxButtons {
+		return fmt.Errorf("whatsapp requires 1 to %v buttons, got %v", WhatsAppMaxButtons, len(buttons))
+	}
+	variables := make(map[string]string, len(buttons))
+	for i, button := range buttons {
+		if utf8.RuneCountInString(button) > WhatsAppMaxButtonTitle {
+			return fmt.Errorf("whatsapp button %q is longer than %v characters", button, WhatsAppMaxButtonTitle)
+		}
+		variables[strconv.Itoa(i+1)] = button
+	}
+	w.SetWhatsAppTemplate(contentSid, variables)
+	return nil
+}
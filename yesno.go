@@ -0,0 +1,56 @@
+package lambdadialogflow
+
+import "strings"
+
+// Answer is the classification of a reply to a yes/no question
+type Answer int
+
+// Possible answers to a yes/no question
+const (
+	AnswerUnclear Answer = iota
+	AnswerYes
+	AnswerNo
+)
+
+// yesNoWords lists the words accepted as yes or no per language
+var yesNoWords = map[string][2][]string{
+	"en": {
+		{"yes", "yeah", "yep", "sure", "ok", "okay", "correct", "right", "agree", "fine"},
+		{"no", "nope", "nah", "don't", "dont", "never", "cancel", "stop", "disagree"},
+	},
+	"de": {
+		{"ja", "jawohl", "klar", "sicher", "ok", "okay", "genau", "richtig", "einverstanden", "gerne"},
+		{"nein", "nee", "niemals", "abbrechen", "stopp"},
+	},
+}
+
+// ClassifyYesNo classifies the reply to a yes/no question in the given
+// language. Replies containing both kinds of words, like "yes, no, wait",
+// are unclear.
+func ClassifyYesNo(text, languageCode string) Answer {
+	words, ok := yesNoWords[strings.ToLower(strings.SplitN(languageCode, "-", 2)[0])]
+	if !ok {
+		words = yesNoWords["en"]
+	}
+	var yes, no bool
+	for _, token := range strings.Fields(normalizeText(strings.Replace(text, "'", "", -1))) {
+		for _, word := range words[0] {
+			yes = yes || token == strings.Replace(word, "'", "", -1)
+		}
+		for _, word := range words[1] {
+			no = no || token == strings.Replace(word, "'", "", -1)
+		}
+	}
+	switch {
+	case yes && !no:
+		return AnswerYes
+	case no && !yes:
+		return AnswerNo
+	}
+	return AnswerUnclear
+}
+
+// YesNo classifies the query text as reply to a yes/no question
+func (w *Agent) YesNo() Answer {
+	return ClassifyYesNo(w.req.GetQueryResult().GetQueryText(), w.req.GetQueryResult().GetLanguageCode())
+}